@@ -0,0 +1,117 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// DefaultBatchConcurrency is the number of tasks Batch runs at once unless WithConcurrency is given.
+const DefaultBatchConcurrency = 8
+
+// ErrNegativeBatchSize is returned by Batch for a negative number of tasks.
+var ErrNegativeBatchSize = errors.New("gohungry: negative batch size")
+
+// BatchResult is the outcome of one task of a batch.
+type BatchResult[Response any] struct {
+	Response *Response
+	Err      error
+}
+
+// batchConfig holds the settings collected by BatchOption.
+type batchConfig struct {
+	concurrency int
+	failFast    bool
+}
+
+// BatchOption modifies how Batch runs its tasks.
+type BatchOption func(c *batchConfig)
+
+// WithConcurrency runs at most 'n' tasks at once. Defaults to DefaultBatchConcurrency.
+func WithConcurrency(n int) BatchOption {
+	return func(c *batchConfig) {
+		c.concurrency = n
+	}
+}
+
+// WithFailFast stops the batch at the first error: running tasks are cancelled and the remaining
+// ones are not started. By default every task runs and all errors are collected.
+func WithFailFast() BatchOption {
+	return func(c *batchConfig) {
+		c.failFast = true
+	}
+}
+
+// Batch runs 'task' for each index from 0 to 'n'-1 with bounded concurrency and returns the results
+// in index order. Tasks receive a context that is done when 'ctx' is done, or on the first error with
+// WithFailFast. Tasks that never started fail with the error of that context.
+// The returned error is the first error with WithFailFast, otherwise all errors joined.
+// A negative 'n' runs no task and returns ErrNegativeBatchSize.
+func Batch[Response any](ctx context.Context, n int, task func(ctx context.Context, i int) (*Response, error), options ...BatchOption) ([]BatchResult[Response], error) {
+	if n < 0 {
+		return nil, fmt.Errorf("%w: %d", ErrNegativeBatchSize, n)
+	}
+	config := &batchConfig{concurrency: DefaultBatchConcurrency}
+	for _, option := range options {
+		option(config)
+	}
+	if config.concurrency < 1 {
+		config.concurrency = 1
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	results := make([]BatchResult[Response], n)
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(config.concurrency, n) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				response, err := task(ctx, i)
+				results[i] = BatchResult[Response]{Response: response, Err: err}
+				if err != nil && config.failFast {
+					cancel(err)
+				}
+			}
+		}()
+	}
+
+	// Hand out the tasks in order until the batch is cancelled
+	next := 0
+send:
+	for ; next < n; next++ {
+		select {
+		case indexes <- next:
+		case <-ctx.Done():
+			break send
+		}
+	}
+	close(indexes)
+	wg.Wait()
+	for i := next; i < n; i++ {
+		results[i].Err = ctx.Err()
+	}
+
+	if config.failFast {
+		// The cause is the first task error, or the error of the parent context
+		return results, context.Cause(ctx)
+	}
+	var errs []error
+	for _, result := range results {
+		if result.Err != nil {
+			errs = append(errs, result.Err)
+		}
+	}
+	return results, errors.Join(errs...)
+}
+
+// DoBatch sends 'requests' with Batch, see Batch for the options and errors.
+func DoBatch[Response any](ctx context.Context, requests []*RequestInfo[Response], options ...BatchOption) ([]BatchResult[Response], error) {
+	return Batch(ctx, len(requests), func(ctx context.Context, i int) (*Response, error) {
+		return DoRequestContext(ctx, requests[i])
+	}, options...)
+}
@@ -0,0 +1,160 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/guhungry/gohungry/http/httptest"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBatchOrderAndConcurrency(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	task := func(ctx context.Context, i int) (*int, error) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			previous := maxInFlight.Load()
+			if current <= previous || maxInFlight.CompareAndSwap(previous, current) {
+				break
+			}
+		}
+		// Later tasks finish first
+		time.Sleep(time.Duration(20-i) * time.Millisecond)
+		return &i, nil
+	}
+
+	results, err := Batch(context.Background(), 20, task, WithConcurrency(4))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for i, result := range results {
+		if result.Err != nil || *result.Response != i {
+			t.Errorf("Expected result %d at index %d, got %v, %v", i, i, result.Response, result.Err)
+		}
+	}
+	if maxInFlight.Load() > 4 {
+		t.Errorf("Expected at most 4 tasks at once, got %d", maxInFlight.Load())
+	}
+}
+
+func TestBatchErrors(t *testing.T) {
+	errOdd := errors.New("odd")
+	task := func(ctx context.Context, i int) (*int, error) {
+		if i%2 == 1 {
+			return nil, fmt.Errorf("task %d: %w", i, errOdd)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(10 * time.Millisecond):
+			return &i, nil
+		}
+	}
+
+	t.Run("Collect All", func(t *testing.T) {
+		results, err := Batch(context.Background(), 6, task, WithConcurrency(2))
+		if !errors.Is(err, errOdd) {
+			t.Fatalf("Expected joined errors, got %v", err)
+		}
+		for i, result := range results {
+			if (result.Err != nil) != (i%2 == 1) {
+				t.Errorf("Expected error only for odd tasks, got %v at %d", result.Err, i)
+			}
+		}
+	})
+
+	t.Run("Fail Fast", func(t *testing.T) {
+		results, err := Batch(context.Background(), 6, task, WithConcurrency(2), WithFailFast())
+		if err == nil || err.Error() != "task 1: odd" {
+			t.Fatalf("Expected first task error, got %v", err)
+		}
+		if !errors.Is(results[5].Err, context.Canceled) {
+			t.Errorf("Expected the last task not to run, got %v", results[5].Err)
+		}
+	})
+}
+
+func TestBatchDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	task := func(ctx context.Context, i int) (*int, error) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(20 * time.Millisecond):
+			return &i, nil
+		}
+	}
+	results, err := Batch(ctx, 10, task, WithConcurrency(1))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected deadline error, got %v", err)
+	}
+	if results[0].Err != nil || !errors.Is(results[9].Err, context.DeadlineExceeded) {
+		t.Errorf("Expected the first task to finish and the last to miss the deadline, got %v and %v", results[0].Err, results[9].Err)
+	}
+}
+
+func TestBatchSize(t *testing.T) {
+	task := func(ctx context.Context, i int) (*int, error) {
+		t.Errorf("Expected no task to run, got task %d", i)
+		return nil, nil
+	}
+
+	if results, err := Batch(context.Background(), 0, task); err != nil || len(results) != 0 {
+		t.Errorf("Expected no results for an empty batch, got %v, %v", results, err)
+	}
+	if _, err := Batch(context.Background(), -1, task); !errors.Is(err, ErrNegativeBatchSize) {
+		t.Errorf("Expected ErrNegativeBatchSize, got %v", err)
+	}
+}
+
+func TestDoBatch(t *testing.T) {
+	SetHTTPClient(&httptest.MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			return httptest.MockHTTPClientSuccess(200, fmt.Sprintf(`{"path":"%s"}`, req.URL.Path)).Do(req)
+		},
+	})
+	defer ResetHTTPClient()
+
+	var requests []*RequestInfo[map[string]string]
+	for i := range 5 {
+		requests = append(requests, NewRequestInfo(MethodGet, fmt.Sprintf("https://example.com/%d", i), nil, httptest.DummyRequestBodySerializer, httptest.DummyResponseBodyParser[map[string]string]))
+	}
+	results, err := DoBatch(context.Background(), requests, WithConcurrency(2))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for i, result := range results {
+		if (*result.Response)["path"] != fmt.Sprintf("/%d", i) {
+			t.Errorf("Expected /%d, got %v", i, result.Response)
+		}
+	}
+}
+
+func TestDoRequestContext(t *testing.T) {
+	SetHTTPClient(&httptest.MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		},
+	})
+	defer ResetHTTPClient()
+
+	// Cancelling the context of the request info stops the request as well
+	requestCtx, cancel := context.WithCancel(context.Background())
+	requestInfo := NewRequestInfo(MethodGet, "https://example.com", nil, httptest.DummyRequestBodySerializer, httptest.DummyResponseBodyParser[map[string]string],
+		WithContext[map[string]string](requestCtx))
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	if _, err := DoRequestContext(context.Background(), requestInfo); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if requestInfo.ctx != requestCtx {
+		t.Error("Expected the request info to keep its context")
+	}
+}
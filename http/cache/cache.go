@@ -0,0 +1,451 @@
+// Package cache provides an HTTP response cache middleware following RFC 9111. It serves
+// fresh responses from a pluggable Storage, honors Cache-Control (max-age, s-maxage, no-store,
+// no-cache, private) and Expires, and revalidates stale responses with ETag and Last-Modified
+// conditional requests. Each response carries its CacheStatus in the X-Gohungry-Cache header,
+// which DoRequest reports through gohungry's Result.
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	gohungry "github.com/guhungry/gohungry/http"
+)
+
+// HTTP caching header constants
+const (
+	headerAge             = "Age"
+	headerCacheControl    = "Cache-Control"
+	headerDate            = "Date"
+	headerETag            = "ETag"
+	headerExpires         = "Expires"
+	headerIfModifiedSince = "If-Modified-Since"
+	headerIfNoneMatch     = "If-None-Match"
+	headerLastModified    = "Last-Modified"
+	headerVary            = "Vary"
+)
+
+// Heuristic freshness settings for responses with Last-Modified but no explicit lifetime (RFC 9111 4.2.2)
+const (
+	heuristicFraction = 10             // Lifetime is a tenth of the time since Last-Modified
+	heuristicMaxAge   = 24 * time.Hour // Upper bound of the heuristic lifetime
+)
+
+// DefaultMaxEntryBytes is the largest response body stored by default. Larger responses are passed through.
+const DefaultMaxEntryBytes int64 = 8 << 20
+
+// cacheableStatus lists the status codes that are cacheable by default (RFC 9110 15.1).
+var cacheableStatus = map[int]bool{
+	http.StatusOK:                   true,
+	http.StatusNonAuthoritativeInfo: true,
+	http.StatusNoContent:            true,
+	http.StatusMultipleChoices:      true,
+	http.StatusMovedPermanently:     true,
+	http.StatusNotFound:             true,
+	http.StatusMethodNotAllowed:     true,
+	http.StatusGone:                 true,
+	http.StatusRequestURITooLong:    true,
+	http.StatusNotImplemented:       true,
+}
+
+// Cache is a response cache middleware.
+type Cache struct {
+	storage       Storage
+	shared        bool
+	maxEntryBytes int64
+	now           func() time.Time
+}
+
+// Option modifies a Cache.
+type Option func(c *Cache)
+
+// WithShared makes the cache behave as a shared cache: it uses s-maxage and does not store
+// private responses or responses to requests with credentials, unless they are marked public,
+// s-maxage or must-revalidate. By default it is a private cache,
+// which keeps separate entries for requests with different credentials, see gohungry's CredentialHeaders.
+func WithShared() Option {
+	return func(c *Cache) {
+		c.shared = true
+	}
+}
+
+// WithMaxEntryBytes sets the largest response body stored. Larger responses are passed through
+// without being buffered in full. Defaults to DefaultMaxEntryBytes.
+func WithMaxEntryBytes(n int64) Option {
+	return func(c *Cache) {
+		c.maxEntryBytes = n
+	}
+}
+
+// New creates a Cache keeping its entries in 'storage'.
+func New(storage Storage, options ...Option) *Cache {
+	result := &Cache{storage: storage, maxEntryBytes: DefaultMaxEntryBytes, now: time.Now}
+	for _, option := range options {
+		option(result)
+	}
+	return result
+}
+
+// Middleware serves requests of a client from the cache when possible.
+func (c *Cache) Middleware() gohungry.Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return gohungry.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return c.roundTrip(next, req)
+		})
+	}
+}
+
+// roundTrip answers 'req' from the cache, revalidates the stored response or forwards it to 'next'.
+func (c *Cache) roundTrip(next http.RoundTripper, req *http.Request) (*http.Response, error) {
+	key := c.key(req)
+	if req.Method != http.MethodGet {
+		return c.invalidate(next, req, key)
+	}
+
+	requestDirectives := parseCacheControl(req.Header)
+	if requestDirectives.has("no-store") {
+		return c.fetch(next, req, key, nil)
+	}
+
+	entry, ok := c.storage.Get(key)
+	if !ok || !entry.matchesVary(req) || c.isInvalidated(req, key, entry) {
+		return c.fetch(next, req, key, nil)
+	}
+	if c.isFresh(entry, requestDirectives) {
+		return entry.response(req, gohungry.CacheHit, c.now()), nil
+	}
+	if !entry.hasValidators() || hasConditionals(req) {
+		return c.fetch(next, req, key, nil)
+	}
+	return c.fetch(next, conditionalRequest(req, entry), key, entry)
+}
+
+// key returns the storage key of 'req'. A private cache serves a single caller, but a client
+// may send requests for several users, so its keys include the credentials identifying the caller.
+// They are hashed to keep them out of the storage.
+func (c *Cache) key(req *http.Request) string {
+	key := req.URL.String()
+	credentials := requestCredentials(req)
+	if c.shared || len(credentials) == 0 {
+		return key
+	}
+
+	hash := sha256.New()
+	for _, credential := range credentials {
+		_, _ = io.WriteString(hash, credential+"\n")
+	}
+	return key + " " + hex.EncodeToString(hash.Sum(nil))
+}
+
+// requestCredentials returns the credential headers of 'req' as "name: value" lines.
+func requestCredentials(req *http.Request) []string {
+	var result []string
+	for _, name := range gohungry.CredentialHeaders(req) {
+		for _, value := range req.Header.Values(name) {
+			result = append(result, name+": "+value)
+		}
+	}
+	return result
+}
+
+// invalidationKey returns the storage key recording when the responses for the URL of 'req' were
+// last invalidated. Entries of other callers cannot be listed, so they are checked against it instead.
+func invalidationKey(req *http.Request) string {
+	return req.URL.String() + " invalidated"
+}
+
+// isInvalidated reports whether 'entry', stored for another key than the URL, was requested before
+// an unsafe request invalidated the URL.
+func (c *Cache) isInvalidated(req *http.Request, key string, entry *Entry) bool {
+	if key == req.URL.String() {
+		return false
+	}
+	marker, ok := c.storage.Get(invalidationKey(req))
+	return ok && entry.RequestTime.Before(marker.RequestTime)
+}
+
+// invalidate forwards an unsafe request and removes the stored responses for its URL when it succeeds,
+// whoever the caller that stored them.
+func (c *Cache) invalidate(next http.RoundTripper, req *http.Request, key string) (*http.Response, error) {
+	res, err := next.RoundTrip(req)
+	if err == nil && req.Method != http.MethodHead && res.StatusCode < http.StatusBadRequest {
+		if err := c.storage.Delete(key); err != nil {
+			log.Println("cache delete error:", err)
+		}
+		if key != req.URL.String() {
+			if err := c.storage.Delete(req.URL.String()); err != nil {
+				log.Println("cache delete error:", err)
+			}
+		}
+		if !c.shared {
+			c.store(invalidationKey(req), &Entry{RequestTime: c.now()})
+		}
+	}
+	return res, err
+}
+
+// fetch sends 'req' and stores the response when allowed. 'stale' is the entry being revalidated, if any.
+func (c *Cache) fetch(next http.RoundTripper, req *http.Request, key string, stale *Entry) (*http.Response, error) {
+	requestTime := c.now()
+	res, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	responseTime := c.now()
+
+	if stale != nil && res.StatusCode == http.StatusNotModified {
+		_, _ = io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+
+		entry := stale.revalidated(res.Header, requestTime, responseTime)
+		c.store(key, entry)
+		return entry.response(req, gohungry.CacheRevalidated, responseTime), nil
+	}
+
+	res.Header.Set(gohungry.HeaderCacheStatus, string(gohungry.CacheMiss))
+	if !c.isStorable(req, res) {
+		return res, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, c.maxEntryBytes+1))
+	if err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+	if int64(len(body)) > c.maxEntryBytes {
+		// Too large to store, the caller reads the rest from the upstream
+		res.Body = &struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), res.Body), res.Body}
+		return res, nil
+	}
+	res.Body.Close()
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	c.store(key, &Entry{
+		StatusCode:   res.StatusCode,
+		Header:       withoutCacheStatus(res.Header),
+		Body:         body,
+		Vary:         varyHeaders(req, res.Header),
+		RequestTime:  requestTime,
+		ResponseTime: responseTime,
+	})
+	return res, nil
+}
+
+// store saves 'entry', logging storage errors since the response is still usable.
+func (c *Cache) store(key string, entry *Entry) {
+	if err := c.storage.Set(key, entry); err != nil {
+		log.Println("cache store error:", err)
+	}
+}
+
+// isStorable reports whether the response to 'req' may be stored (RFC 9111 3).
+func (c *Cache) isStorable(req *http.Request, res *http.Response) bool {
+	if !cacheableStatus[res.StatusCode] || parseCacheControl(req.Header).has("no-store") {
+		return false
+	}
+
+	directives := parseCacheControl(res.Header)
+	switch {
+	case directives.has("no-store"):
+		return false
+	case c.shared && directives.has("private"):
+		return false
+	case c.shared && len(requestCredentials(req)) > 0 &&
+		!directives.has("public") && !directives.has("s-maxage") && !directives.has("must-revalidate"):
+		return false
+	case strings.TrimSpace(res.Header.Get(headerVary)) == "*":
+		return false
+	}
+
+	// Only store responses that can be reused fresh or revalidated later
+	return directives.has("max-age") || directives.has("s-maxage") || directives.has("public") ||
+		res.Header.Get(headerExpires) != "" || res.Header.Get(headerETag) != "" || res.Header.Get(headerLastModified) != ""
+}
+
+// isFresh reports whether 'entry' can be served without revalidation (RFC 9111 4.2).
+func (c *Cache) isFresh(entry *Entry, requestDirectives directives) bool {
+	responseDirectives := parseCacheControl(entry.Header)
+	if responseDirectives.has("no-cache") || requestDirectives.has("no-cache") {
+		return false
+	}
+
+	age := entry.age(c.now())
+	if maxAge, ok := requestDirectives.seconds("max-age"); ok && age > maxAge {
+		return false
+	}
+	return age < c.freshnessLifetime(entry, responseDirectives)
+}
+
+// freshnessLifetime returns how long 'entry' stays fresh after it was generated (RFC 9111 4.2.1).
+func (c *Cache) freshnessLifetime(entry *Entry, responseDirectives directives) time.Duration {
+	if c.shared {
+		if lifetime, ok := responseDirectives.seconds("s-maxage"); ok {
+			return lifetime
+		}
+	}
+	if lifetime, ok := responseDirectives.seconds("max-age"); ok {
+		return lifetime
+	}
+
+	date := entry.date()
+	if expires := entry.Header.Get(headerExpires); expires != "" {
+		expiresAt, err := http.ParseTime(expires)
+		if err != nil {
+			return 0
+		}
+		return expiresAt.Sub(date)
+	}
+	if lastModified, err := http.ParseTime(entry.Header.Get(headerLastModified)); err == nil {
+		return min(date.Sub(lastModified)/heuristicFraction, heuristicMaxAge)
+	}
+	return 0
+}
+
+// date returns the Date header of the entry, or the time it was received.
+func (e *Entry) date() time.Time {
+	if date, err := http.ParseTime(e.Header.Get(headerDate)); err == nil {
+		return date
+	}
+	return e.ResponseTime
+}
+
+// age returns the current age of the entry at 'now' (RFC 9111 4.2.3).
+func (e *Entry) age(now time.Time) time.Duration {
+	apparentAge := max(0, e.ResponseTime.Sub(e.date()))
+	ageValue, _ := strconv.Atoi(e.Header.Get(headerAge))
+	correctedAge := time.Duration(ageValue)*time.Second + e.ResponseTime.Sub(e.RequestTime)
+	return max(apparentAge, correctedAge) + now.Sub(e.ResponseTime)
+}
+
+// hasValidators reports whether the entry can be revalidated with a conditional request.
+func (e *Entry) hasValidators() bool {
+	return e.Header.Get(headerETag) != "" || e.Header.Get(headerLastModified) != ""
+}
+
+// matchesVary reports whether 'req' selects the same representation as the stored request.
+func (e *Entry) matchesVary(req *http.Request) bool {
+	for name, values := range e.Vary {
+		if strings.Join(req.Header.Values(name), ",") != strings.Join(values, ",") {
+			return false
+		}
+	}
+	return true
+}
+
+// revalidated returns a copy of the entry updated with the headers of a 304 response.
+func (e *Entry) revalidated(header http.Header, requestTime, responseTime time.Time) *Entry {
+	result := *e
+	result.Header = e.Header.Clone()
+	for name, values := range withoutCacheStatus(header) {
+		switch http.CanonicalHeaderKey(name) {
+		case "Content-Length", "Content-Encoding", "Transfer-Encoding":
+			// These describe the empty 304 body, not the stored one
+		default:
+			result.Header[name] = values
+		}
+	}
+	result.RequestTime = requestTime
+	result.ResponseTime = responseTime
+	return &result
+}
+
+// response builds a response to 'req' from the entry.
+func (e *Entry) response(req *http.Request, status gohungry.CacheStatus, now time.Time) *http.Response {
+	header := e.Header.Clone()
+	header.Set(headerAge, strconv.Itoa(int(e.age(now).Seconds())))
+	header.Set(gohungry.HeaderCacheStatus, string(status))
+	return &http.Response{
+		Status:        strconv.Itoa(e.StatusCode) + " " + http.StatusText(e.StatusCode),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// hasConditionals reports whether the caller already made 'req' conditional.
+func hasConditionals(req *http.Request) bool {
+	return req.Header.Get(headerIfNoneMatch) != "" || req.Header.Get(headerIfModifiedSince) != ""
+}
+
+// conditionalRequest returns a copy of 'req' asking the upstream to confirm 'entry' is still current.
+func conditionalRequest(req *http.Request, entry *Entry) *http.Request {
+	result := req.Clone(req.Context())
+	if etag := entry.Header.Get(headerETag); etag != "" {
+		result.Header.Set(headerIfNoneMatch, etag)
+	}
+	if lastModified := entry.Header.Get(headerLastModified); lastModified != "" {
+		result.Header.Set(headerIfModifiedSince, lastModified)
+	}
+	return result
+}
+
+// varyHeaders returns the request headers named by the Vary header of the response.
+func varyHeaders(req *http.Request, header http.Header) http.Header {
+	result := make(http.Header)
+	for _, value := range header.Values(headerVary) {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				result[http.CanonicalHeaderKey(name)] = req.Header.Values(name)
+			}
+		}
+	}
+	return result
+}
+
+// withoutCacheStatus returns a copy of 'header' without the cache status header.
+func withoutCacheStatus(header http.Header) http.Header {
+	result := header.Clone()
+	result.Del(gohungry.HeaderCacheStatus)
+	return result
+}
+
+// directives holds parsed Cache-Control directives, keyed by lower case name.
+type directives map[string]string
+
+// parseCacheControl parses every Cache-Control header in 'header'.
+func parseCacheControl(header http.Header) directives {
+	result := make(directives)
+	for _, value := range header.Values(headerCacheControl) {
+		for _, directive := range strings.Split(value, ",") {
+			name, argument, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			if name != "" {
+				result[strings.ToLower(name)] = strings.Trim(argument, `"`)
+			}
+		}
+	}
+	return result
+}
+
+// has reports whether the directive 'name' is present.
+func (d directives) has(name string) bool {
+	_, ok := d[name]
+	return ok
+}
+
+// seconds returns the delta-seconds argument of the directive 'name'.
+func (d directives) seconds(name string) (time.Duration, bool) {
+	argument, ok := d[name]
+	if !ok {
+		return 0, false
+	}
+	value, err := strconv.Atoi(argument)
+	if err != nil || value < 0 {
+		return 0, false
+	}
+	return time.Duration(value) * time.Second, true
+}
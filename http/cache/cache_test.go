@@ -0,0 +1,315 @@
+package cache
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	gohungry "github.com/guhungry/gohungry/http"
+)
+
+// upstream is a fake server answering with a configurable response and counting requests.
+type upstream struct {
+	status   int
+	header   http.Header
+	body     string
+	requests []*http.Request
+}
+
+// RoundTrip records 'req' and returns the configured response, or 304 when the ETag matches.
+func (u *upstream) RoundTrip(req *http.Request) (*http.Response, error) {
+	u.requests = append(u.requests, req)
+	status := u.status
+	if etag := u.header.Get("ETag"); etag != "" && req.Header.Get("If-None-Match") == etag {
+		status = http.StatusNotModified
+	}
+	return &http.Response{StatusCode: status, Header: u.header.Clone(), Body: io.NopCloser(strings.NewReader(u.body))}, nil
+}
+
+// newTestCache returns a cache in front of 'up' and a function moving its clock forward.
+func newTestCache(up *upstream, options ...Option) (http.RoundTripper, func(time.Duration)) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := New(NewMemoryStorage(10), options...)
+	c.now = func() time.Time { return now }
+	return c.Middleware()(up), func(d time.Duration) { now = now.Add(d) }
+}
+
+// get sends a GET request and returns the response body and cache status.
+func get(t *testing.T, transport http.RoundTripper, header http.Header) (string, gohungry.CacheStatus) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/data", nil)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	res, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+	return string(body), gohungry.CacheStatus(res.Header.Get(gohungry.HeaderCacheStatus))
+}
+
+func TestCacheMaxAge(t *testing.T) {
+	up := &upstream{status: 200, header: http.Header{"Cache-Control": {"max-age=60"}}, body: "data"}
+	transport, advance := newTestCache(up)
+
+	expected := []gohungry.CacheStatus{gohungry.CacheMiss, gohungry.CacheHit}
+	for _, status := range expected {
+		body, result := get(t, transport, nil)
+		if body != "data" || result != status {
+			t.Errorf("Expected data with %s, got %s with %s", status, body, result)
+		}
+	}
+
+	advance(61 * time.Second)
+	if _, result := get(t, transport, nil); result != gohungry.CacheMiss {
+		t.Errorf("Expected expired entry to miss, got %s", result)
+	}
+	if len(up.requests) != 2 {
+		t.Errorf("Expected 2 upstream requests, got %d", len(up.requests))
+	}
+}
+
+func TestCacheExpires(t *testing.T) {
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	up := &upstream{status: 200, header: http.Header{
+		"Date":    {date.Format(http.TimeFormat)},
+		"Expires": {date.Add(time.Minute).Format(http.TimeFormat)},
+	}, body: "data"}
+	transport, advance := newTestCache(up)
+
+	get(t, transport, nil)
+	advance(30 * time.Second)
+	if _, result := get(t, transport, nil); result != gohungry.CacheHit {
+		t.Errorf("Expected hit before Expires, got %s", result)
+	}
+	advance(time.Minute)
+	if _, result := get(t, transport, nil); result != gohungry.CacheMiss {
+		t.Errorf("Expected miss after Expires, got %s", result)
+	}
+}
+
+func TestCacheRevalidation(t *testing.T) {
+	up := &upstream{status: 200, header: http.Header{"Cache-Control": {"no-cache"}, "Etag": {`"v1"`}}, body: "data"}
+	transport, _ := newTestCache(up)
+
+	get(t, transport, nil)
+	body, result := get(t, transport, nil)
+	if body != "data" || result != gohungry.CacheRevalidated {
+		t.Errorf("Expected revalidated data, got %s with %s", body, result)
+	}
+	if inm := up.requests[1].Header.Get("If-None-Match"); inm != `"v1"` {
+		t.Errorf("Expected conditional request with If-None-Match, got %q", inm)
+	}
+
+	// A changed resource replaces the stored one
+	up.header.Set("ETag", `"v2"`)
+	up.body = "new data"
+	if body, result := get(t, transport, nil); body != "new data" || result != gohungry.CacheMiss {
+		t.Errorf("Expected new data with MISS, got %s with %s", body, result)
+	}
+}
+
+func TestCacheLastModifiedRevalidation(t *testing.T) {
+	lastModified := time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat)
+	up := &upstream{status: 200, header: http.Header{"Last-Modified": {lastModified}, "Cache-Control": {"max-age=0"}}, body: "data"}
+	transport, _ := newTestCache(up)
+
+	get(t, transport, nil)
+	get(t, transport, nil)
+	if ims := up.requests[1].Header.Get("If-Modified-Since"); ims != lastModified {
+		t.Errorf("Expected conditional request with If-Modified-Since, got %q", ims)
+	}
+}
+
+func TestCacheNotStored(t *testing.T) {
+	tests := []struct {
+		name          string
+		header        http.Header
+		requestHeader http.Header
+		options       []Option
+	}{
+		{name: "No Store", header: http.Header{"Cache-Control": {"no-store, max-age=60"}}},
+		{name: "Request No Store", header: http.Header{"Cache-Control": {"max-age=60"}}, requestHeader: http.Header{"Cache-Control": {"no-store"}}},
+		{name: "No Freshness Or Validators", header: http.Header{}},
+		{name: "Vary Star", header: http.Header{"Cache-Control": {"max-age=60"}, "Vary": {"*"}}},
+		{name: "Private In Shared Cache", header: http.Header{"Cache-Control": {"private, max-age=60"}}, options: []Option{WithShared()}},
+		{name: "Authorized In Shared Cache", header: http.Header{"Cache-Control": {"max-age=60"}}, requestHeader: http.Header{"Authorization": {"Bearer token"}}, options: []Option{WithShared()}},
+		{name: "Cookie In Shared Cache", header: http.Header{"Cache-Control": {"max-age=60"}}, requestHeader: http.Header{"Cookie": {"session=alice"}}, options: []Option{WithShared()}},
+		{name: "Proxy Authorized In Shared Cache", header: http.Header{"Cache-Control": {"max-age=60"}}, requestHeader: http.Header{"Proxy-Authorization": {"Basic token"}}, options: []Option{WithShared()}},
+		{name: "Too Large", header: http.Header{"Cache-Control": {"max-age=60"}}, options: []Option{WithMaxEntryBytes(3)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			up := &upstream{status: 200, header: tt.header, body: "data"}
+			transport, _ := newTestCache(up, tt.options...)
+			get(t, transport, tt.requestHeader)
+			if _, result := get(t, transport, tt.requestHeader); result != gohungry.CacheMiss {
+				t.Errorf("Expected miss, got %s", result)
+			}
+		})
+	}
+}
+
+func TestCachePrivateInPrivateCache(t *testing.T) {
+	up := &upstream{status: 200, header: http.Header{"Cache-Control": {"private, max-age=60"}}, body: "data"}
+	transport, _ := newTestCache(up)
+	get(t, transport, nil)
+	if _, result := get(t, transport, nil); result != gohungry.CacheHit {
+		t.Errorf("Expected private response to be cached by a private cache, got %s", result)
+	}
+}
+
+func TestCacheSeparatesCallers(t *testing.T) {
+	c := New(NewMemoryStorage(10))
+	transport := c.Middleware()(gohungry.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		caller := req.Header.Get("Authorization") + req.Header.Get("Cookie") + req.Header.Get("Api-Key")
+		header := http.Header{"Cache-Control": {"private, max-age=60"}}
+		return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(strings.NewReader("data for " + caller))}, nil
+	}))
+	gohungry.SetHTTPClient(&http.Client{Transport: transport})
+	defer gohungry.ResetHTTPClient()
+
+	tests := []struct {
+		name     string
+		option   gohungry.RequestInfoOption[string]
+		expected string
+	}{
+		{name: "Bearer Alice", option: gohungry.WithAuthBearer[string]("alice"), expected: "data for Bearer alice"},
+		{name: "Bearer Bob", option: gohungry.WithAuthBearer[string]("bob"), expected: "data for Bearer bob"},
+		{name: "Cookie", option: gohungry.WithHeader[string]("Cookie", "session=carol"), expected: "data for session=carol"},
+		{name: "API Key Header", option: gohungry.WithAuthAPIKey[string](gohungry.APIKeyInHeader, "Api-Key", "dave"), expected: "data for dave"},
+		{name: "Anonymous", option: gohungry.WithHeader[string]("Accept", "text/plain"), expected: "data for "},
+		{name: "Bearer Alice Again", option: gohungry.WithAuthBearer[string]("alice"), expected: "data for Bearer alice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := gohungry.NewRequestInfo(gohungry.MethodGet, "http://example.com/data", nil, nil, parseMessage, tt.option)
+			message, err := gohungry.DoRequest(request)
+			if err != nil || *message != tt.expected {
+				t.Errorf("Expected %s, got %v, %v", tt.expected, message, err)
+			}
+		})
+	}
+}
+
+func TestCacheSharedAPIKey(t *testing.T) {
+	up := &upstream{status: 200, header: http.Header{"Cache-Control": {"max-age=60"}}, body: `{"message":"data"}`}
+	transport, _ := newTestCache(up, WithShared())
+	gohungry.SetHTTPClient(&http.Client{Transport: transport})
+	defer gohungry.ResetHTTPClient()
+
+	for range 2 {
+		request := gohungry.NewRequestInfo(gohungry.MethodGet, "http://example.com/data", nil, nil, parseMessage,
+			gohungry.WithAuthAPIKey[string](gohungry.APIKeyInHeader, "Api-Key", "alice"))
+		if _, err := gohungry.DoRequest(request); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if len(up.requests) != 2 {
+		t.Errorf("Expected the response to an API key request not to be shared, got %d requests", len(up.requests))
+	}
+}
+
+func TestCacheTooLarge(t *testing.T) {
+	up := &upstream{status: 200, header: http.Header{"Cache-Control": {"max-age=60"}}, body: "too large"}
+	transport, _ := newTestCache(up, WithMaxEntryBytes(3))
+	for range 2 {
+		if body, result := get(t, transport, nil); body != "too large" || result != gohungry.CacheMiss {
+			t.Errorf("Expected the whole body passed through, got %s, %s", body, result)
+		}
+	}
+}
+
+func TestCacheRequestNoCache(t *testing.T) {
+	up := &upstream{status: 200, header: http.Header{"Cache-Control": {"max-age=60"}, "Etag": {`"v1"`}}, body: "data"}
+	transport, _ := newTestCache(up)
+	get(t, transport, nil)
+	if _, result := get(t, transport, http.Header{"Cache-Control": {"no-cache"}}); result != gohungry.CacheRevalidated {
+		t.Errorf("Expected request no-cache to revalidate, got %s", result)
+	}
+}
+
+func TestCacheVary(t *testing.T) {
+	up := &upstream{status: 200, header: http.Header{"Cache-Control": {"max-age=60"}, "Vary": {"Accept-Language"}}, body: "data"}
+	transport, _ := newTestCache(up)
+
+	get(t, transport, http.Header{"Accept-Language": {"th"}})
+	if _, result := get(t, transport, http.Header{"Accept-Language": {"th"}}); result != gohungry.CacheHit {
+		t.Errorf("Expected hit for the same language, got %s", result)
+	}
+	if _, result := get(t, transport, http.Header{"Accept-Language": {"en"}}); result != gohungry.CacheMiss {
+		t.Errorf("Expected miss for another language, got %s", result)
+	}
+}
+
+func TestCacheUnsafeMethodInvalidates(t *testing.T) {
+	up := &upstream{status: 200, header: http.Header{"Cache-Control": {"max-age=60"}}, body: "data"}
+	transport, _ := newTestCache(up)
+	get(t, transport, nil)
+
+	req, _ := http.NewRequest(http.MethodPost, "http://example.com/data", strings.NewReader("update"))
+	res, _ := transport.RoundTrip(req)
+	res.Body.Close()
+
+	if _, result := get(t, transport, nil); result != gohungry.CacheMiss {
+		t.Errorf("Expected POST to invalidate the entry, got %s", result)
+	}
+}
+
+func TestCacheUnsafeMethodInvalidatesCallers(t *testing.T) {
+	up := &upstream{status: 200, header: http.Header{"Cache-Control": {"private, max-age=60"}}, body: "data"}
+	transport, advance := newTestCache(up)
+	alice, bob := http.Header{"Authorization": {"Bearer alice"}}, http.Header{"Authorization": {"Bearer bob"}}
+	get(t, transport, alice)
+	get(t, transport, bob)
+	advance(time.Second)
+
+	req, _ := http.NewRequest(http.MethodPut, "http://example.com/data", strings.NewReader("update"))
+	req.Header.Set("Authorization", "Bearer alice")
+	res, _ := transport.RoundTrip(req)
+	res.Body.Close()
+	advance(time.Second)
+
+	// Every caller's entry is invalidated, and stored again on the next request
+	expected := []struct {
+		header http.Header
+		status gohungry.CacheStatus
+	}{{alice, gohungry.CacheMiss}, {bob, gohungry.CacheMiss}, {bob, gohungry.CacheHit}}
+	for _, e := range expected {
+		if _, result := get(t, transport, e.header); result != e.status {
+			t.Errorf("Expected %s for %s, got %s", e.status, e.header.Get("Authorization"), result)
+		}
+	}
+}
+
+func TestCacheThroughDoRequest(t *testing.T) {
+	up := &upstream{status: 200, header: http.Header{"Cache-Control": {"max-age=60"}}, body: `{"message":"success"}`}
+	transport, _ := newTestCache(up)
+	gohungry.SetHTTPClient(&http.Client{Transport: transport})
+	defer gohungry.ResetHTTPClient()
+
+	for _, expected := range []gohungry.CacheStatus{gohungry.CacheMiss, gohungry.CacheHit} {
+		var result gohungry.Result
+		request := gohungry.NewRequestInfo(gohungry.MethodGet, "http://example.com/data", nil, nil, parseMessage, gohungry.WithResult[string](&result))
+		message, err := gohungry.DoRequest(request)
+		if err != nil || *message != `{"message":"success"}` {
+			t.Fatalf("Expected message, got %v, %v", message, err)
+		}
+		if result.CacheStatus != expected || result.StatusCode != http.StatusOK {
+			t.Errorf("Expected %s with status 200, got %s with %d", expected, result.CacheStatus, result.StatusCode)
+		}
+	}
+}
+
+// parseMessage returns the response body as a string.
+func parseMessage(reader io.ReadCloser) (*string, error) {
+	body, err := io.ReadAll(reader)
+	result := string(body)
+	return &result, err
+}
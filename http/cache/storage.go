@@ -0,0 +1,169 @@
+package cache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Entry is a stored response.
+type Entry struct {
+	StatusCode   int         // HTTP status code
+	Header       http.Header // Response headers
+	Body         []byte      // Response body
+	Vary         http.Header // Request headers selected by the Vary response header
+	RequestTime  time.Time   // Time the request that produced the response was sent
+	ResponseTime time.Time   // Time the response was received
+}
+
+// Storage stores entries by key. Implementations must be safe for concurrent use
+// and must not modify an Entry after returning it.
+type Storage interface {
+	// Get returns the entry stored for 'key'.
+	Get(key string) (*Entry, bool)
+	// Set stores 'entry' for 'key', replacing the previous one.
+	Set(key string, entry *Entry) error
+	// Delete removes the entry stored for 'key', if any.
+	Delete(key string) error
+}
+
+// MemoryStorage is an in-memory Storage that evicts the least recently used entry when full.
+type MemoryStorage struct {
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List
+	items      map[string]*list.Element
+}
+
+// memoryItem is an element of MemoryStorage.order.
+type memoryItem struct {
+	key   string
+	entry *Entry
+}
+
+// NewMemoryStorage creates a MemoryStorage holding at most 'maxEntries' entries. Zero means no limit.
+func NewMemoryStorage(maxEntries int) *MemoryStorage {
+	return &MemoryStorage{maxEntries: maxEntries, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+// Get returns the entry stored for 'key' and marks it as recently used.
+func (s *MemoryStorage) Get(key string) (*Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+	s.order.MoveToFront(element)
+	return element.Value.(*memoryItem).entry, true
+}
+
+// Set stores 'entry' for 'key', evicting the least recently used entry when full.
+func (s *MemoryStorage) Set(key string, entry *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.items[key]; ok {
+		element.Value.(*memoryItem).entry = entry
+		s.order.MoveToFront(element)
+		return nil
+	}
+
+	s.items[key] = s.order.PushFront(&memoryItem{key: key, entry: entry})
+	if s.maxEntries > 0 && s.order.Len() > s.maxEntries {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.items, oldest.Value.(*memoryItem).key)
+	}
+	return nil
+}
+
+// Delete removes the entry stored for 'key'.
+func (s *MemoryStorage) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.items[key]; ok {
+		s.order.Remove(element)
+		delete(s.items, key)
+	}
+	return nil
+}
+
+// Len returns the number of stored entries.
+func (s *MemoryStorage) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.order.Len()
+}
+
+// DiskStorage is a Storage keeping one gob encoded file per entry in a directory.
+type DiskStorage struct {
+	dir string
+}
+
+// NewDiskStorage creates a DiskStorage in 'dir', creating the directory when missing.
+func NewDiskStorage(dir string) (*DiskStorage, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &DiskStorage{dir: dir}, nil
+}
+
+// Get returns the entry stored for 'key'. Unreadable files are treated as missing.
+func (s *DiskStorage) Get(key string) (*Entry, bool) {
+	file, err := os.Open(s.path(key))
+	if err != nil {
+		return nil, false
+	}
+	defer file.Close()
+
+	var entry Entry
+	if err := gob.NewDecoder(file).Decode(&entry); err != nil {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// Set writes 'entry' to the file for 'key'. The file is replaced atomically.
+func (s *DiskStorage) Set(key string, entry *Entry) error {
+	file, err := os.CreateTemp(s.dir, "entry-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if err := gob.NewEncoder(file).Encode(entry); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), s.path(key))
+}
+
+// Delete removes the file for 'key'.
+func (s *DiskStorage) Delete(key string) error {
+	err := os.Remove(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// path returns the file name for 'key'.
+func (s *DiskStorage) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:]))
+}
@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMemoryStorageEvictsLeastRecentlyUsed(t *testing.T) {
+	storage := NewMemoryStorage(2)
+	_ = storage.Set("a", &Entry{Body: []byte("a")})
+	_ = storage.Set("b", &Entry{Body: []byte("b")})
+	storage.Get("a")
+	_ = storage.Set("c", &Entry{Body: []byte("c")})
+
+	if _, ok := storage.Get("b"); ok {
+		t.Error("Expected least recently used entry b to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := storage.Get(key); !ok {
+			t.Errorf("Expected entry %s to be kept", key)
+		}
+	}
+	if storage.Len() != 2 {
+		t.Errorf("Expected 2 entries, got %d", storage.Len())
+	}
+
+	_ = storage.Delete("a")
+	if _, ok := storage.Get("a"); ok {
+		t.Error("Expected entry a to be deleted")
+	}
+}
+
+func TestDiskStorage(t *testing.T) {
+	storage, err := NewDiskStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	entry := &Entry{
+		StatusCode:   200,
+		Header:       http.Header{"Etag": {`"v1"`}},
+		Body:         []byte("data"),
+		Vary:         http.Header{"Accept": {"application/json"}},
+		RequestTime:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		ResponseTime: time.Date(2024, 1, 1, 0, 0, 1, 0, time.UTC),
+	}
+
+	if _, ok := storage.Get("key"); ok {
+		t.Error("Expected missing entry")
+	}
+	if err := storage.Set("key", entry); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	stored, ok := storage.Get("key")
+	if !ok || stored.StatusCode != 200 || string(stored.Body) != "data" || stored.Header.Get("ETag") != `"v1"` ||
+		stored.Vary.Get("Accept") != "application/json" || !stored.ResponseTime.Equal(entry.ResponseTime) {
+		t.Errorf("Expected stored entry %+v, got %+v", entry, stored)
+	}
+
+	if err := storage.Delete("key"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, ok := storage.Get("key"); ok {
+		t.Error("Expected entry to be deleted")
+	}
+	if err := storage.Delete("key"); err != nil {
+		t.Errorf("Expected deleting a missing entry to succeed, got %v", err)
+	}
+}
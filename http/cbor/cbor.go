@@ -0,0 +1,48 @@
+// Package cbor provides utilities for making HTTP requests with CBOR payloads
+// and parsing CBOR responses. It includes functions for performing GET and POST
+// requests with CBOR content, along with customizable options for request configuration.
+package cbor
+
+import (
+	"github.com/guhungry/gohungry/http"
+	"io"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// contentType specifies the MIME type for CBOR content.
+const contentType = "application/cbor"
+
+// Get performs an HTTP GET, decodes CBOR response into 'Response'.
+// 'url' is the request target, 'options' customize the request.
+func Get[Response any](url string, options ...http.RequestInfoOption[Response]) (*Response, error) {
+	return requestCBOR[Response](http.MethodGet, url, nil, options...)
+}
+
+// Post performs an HTTP POST with CBOR body, decodes response into 'Response'.
+// 'url' is the request target, 'body' is the payload or an http.StreamBody, 'options' customize the request.
+func Post[Response any](url string, body any, options ...http.RequestInfoOption[Response]) (*Response, error) {
+	return requestCBOR[Response](http.MethodPost, url, body, options...)
+}
+
+// requestCBOR sends an HTTP request and decodes CBOR response into 'Response'.
+// 'method' is the HTTP method, 'url' is the request target, 'body' is the payload for POST,
+// 'options' customize the request.
+func requestCBOR[Response any](method string, url string, body any, options ...http.RequestInfoOption[Response]) (*Response, error) {
+	options = append(options,
+		http.WithAccept[Response](contentType),
+		http.WithContentType[Response](contentType),
+	)
+	request := http.NewRequestInfo(method, url, body, cbor.Marshal, toResponseObject[Response], options...)
+	return http.DoRequest(request)
+}
+
+// toResponseObject decodes CBOR into 'Response'.
+func toResponseObject[Response any](reader io.ReadCloser) (*Response, error) {
+	var result Response
+	decoder := cbor.NewDecoder(reader)
+	if err := decoder.Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
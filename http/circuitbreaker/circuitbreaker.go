@@ -0,0 +1,400 @@
+// Package circuitbreaker stops sending requests to failing upstreams. A Breaker tracks the
+// failure ratio over a rolling window and opens once it crosses a threshold, rejecting requests
+// with ErrCircuitOpen until the open duration passes. It then lets a few probe requests through
+// (half-open) and closes again when they succeed. Use a Breaker per client, or a HostBreaker to
+// keep separate state for each host, and attach it with gohungry's WithMiddleware client option.
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	gohungry "github.com/guhungry/gohungry/http"
+)
+
+// ErrCircuitOpen is returned instead of sending a request while the circuit is open.
+var ErrCircuitOpen = errors.New("gohungry: circuit breaker is open")
+
+// State is the state of a circuit.
+type State int
+
+// Circuit states
+const (
+	StateClosed   State = iota // Requests flow normally and failures are counted
+	StateOpen                  // Requests are rejected with ErrCircuitOpen
+	StateHalfOpen              // A limited number of probe requests test the upstream
+)
+
+// String returns the name of the state.
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// Default breaker settings
+const (
+	DefaultFailureRatio   = 0.5              // Ratio of failed requests that opens the circuit
+	DefaultMinRequests    = 10               // Requests in the window before the ratio is evaluated
+	DefaultWindow         = 60 * time.Second // Length of the rolling window
+	DefaultOpenDuration   = 30 * time.Second // Time the circuit stays open before probing
+	DefaultHalfOpenProbes = 1                // Successful probes needed to close the circuit
+)
+
+// windowBuckets is the number of buckets the rolling window is divided into.
+const windowBuckets = 10
+
+// StateChangeFunc is called when the circuit 'name' changes state. 'name' is the host for HostBreaker.
+type StateChangeFunc func(name string, from, to State)
+
+// FailureFunc reports whether the outcome of a request counts as a failure.
+type FailureFunc func(res *http.Response, err error) bool
+
+// config holds the settings collected by Option.
+type config struct {
+	failureRatio   float64
+	minRequests    int
+	window         time.Duration
+	openDuration   time.Duration
+	halfOpenProbes int
+	onStateChange  StateChangeFunc
+	isFailure      FailureFunc
+}
+
+// Option modifies the settings of a Breaker.
+type Option func(c *config)
+
+// WithFailureRatio sets the ratio of failed requests in the window, between 0 and 1, that opens the circuit.
+func WithFailureRatio(ratio float64) Option {
+	return func(c *config) {
+		c.failureRatio = ratio
+	}
+}
+
+// WithMinRequests sets how many requests the window needs before the failure ratio is evaluated.
+func WithMinRequests(n int) Option {
+	return func(c *config) {
+		c.minRequests = n
+	}
+}
+
+// WithWindow sets the length of the rolling window the failure ratio is computed over.
+func WithWindow(window time.Duration) Option {
+	return func(c *config) {
+		c.window = window
+	}
+}
+
+// WithOpenDuration sets how long the circuit stays open before probing the upstream.
+func WithOpenDuration(duration time.Duration) Option {
+	return func(c *config) {
+		c.openDuration = duration
+	}
+}
+
+// WithHalfOpenProbes sets how many probe requests are let through while half-open.
+// All of them have to succeed to close the circuit.
+func WithHalfOpenProbes(n int) Option {
+	return func(c *config) {
+		c.halfOpenProbes = n
+	}
+}
+
+// WithOnStateChange registers a callback for state changes, e.g. for alerting.
+// It is called synchronously, so it should return quickly.
+func WithOnStateChange(callback StateChangeFunc) Option {
+	return func(c *config) {
+		c.onStateChange = callback
+	}
+}
+
+// WithFailureFunc replaces the default failure check, which counts transport errors
+// and 5xx responses. Requests cancelled by the caller are not counted either way.
+func WithFailureFunc(isFailure FailureFunc) Option {
+	return func(c *config) {
+		c.isFailure = isFailure
+	}
+}
+
+// newConfig applies 'options' on top of the defaults.
+func newConfig(options []Option) *config {
+	result := &config{
+		failureRatio:   DefaultFailureRatio,
+		minRequests:    DefaultMinRequests,
+		window:         DefaultWindow,
+		openDuration:   DefaultOpenDuration,
+		halfOpenProbes: DefaultHalfOpenProbes,
+		isFailure:      isFailure,
+	}
+	for _, option := range options {
+		option(result)
+	}
+	if result.halfOpenProbes < 1 {
+		result.halfOpenProbes = 1
+	}
+	return result
+}
+
+// isFailure counts transport errors and server errors as failures.
+func isFailure(res *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled)
+	}
+	return res.StatusCode >= http.StatusInternalServerError
+}
+
+// Breaker is a circuit breaker for a single upstream.
+type Breaker struct {
+	name     string
+	config   *config
+	mu       sync.Mutex
+	state    State
+	openedAt time.Time
+	probes   int    // Probes in flight or succeeded while half-open
+	passed   int    // Probes succeeded while half-open
+	epoch    uint64 // Incremented on every state change, so late outcomes can be told apart
+	window   *window
+}
+
+// New creates a closed Breaker configured with 'options'.
+func New(options ...Option) *Breaker {
+	return newBreaker("", newConfig(options))
+}
+
+// newBreaker creates a closed Breaker named 'name'.
+func newBreaker(name string, config *config) *Breaker {
+	return &Breaker{name: name, config: config, window: newWindow(config.window)}
+}
+
+// State returns the current state of the circuit.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := b.state
+	if state == StateOpen && time.Since(b.openedAt) >= b.config.openDuration {
+		state = StateHalfOpen
+	}
+	return state
+}
+
+// Permit is a request let through by a Breaker. Either Done or Cancel must be called once the
+// request ends, or a half-open circuit keeps waiting for the probe.
+type Permit struct {
+	breaker  *Breaker
+	epoch    uint64 // State in which the request was let through
+	finished atomic.Bool
+}
+
+// Allow lets a request through unless the circuit is open or enough probes are in flight,
+// in which case the error is ErrCircuitOpen.
+func (b *Breaker) Allow() (*Permit, error) {
+	b.mu.Lock()
+	from := b.state
+	now := time.Now()
+	if b.state == StateOpen && now.Sub(b.openedAt) >= b.config.openDuration {
+		b.setState(StateHalfOpen, now)
+	}
+
+	var err error
+	switch {
+	case b.state == StateOpen, b.state == StateHalfOpen && b.probes >= b.config.halfOpenProbes:
+		err = ErrCircuitOpen
+	case b.state == StateHalfOpen:
+		b.probes++
+	}
+	to, epoch := b.state, b.epoch
+	b.mu.Unlock()
+
+	b.notify(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return &Permit{breaker: b, epoch: epoch}, nil
+}
+
+// Done records the outcome of the request. Outcomes of requests let through before
+// the last state change say nothing about the current state, so they are dropped.
+func (p *Permit) Done(failed bool) {
+	if !p.finished.CompareAndSwap(false, true) {
+		return
+	}
+	b := p.breaker
+	b.mu.Lock()
+	from := b.state
+	now := time.Now()
+
+	switch {
+	case p.epoch != b.epoch:
+		// Let through in an earlier state, e.g. closed requests finishing while half-open
+	case b.state == StateClosed:
+		b.window.record(now, failed)
+		total, failures := b.window.counts(now)
+		if failed && total >= b.config.minRequests && float64(failures)/float64(total) >= b.config.failureRatio {
+			b.setState(StateOpen, now)
+		}
+	case b.state == StateHalfOpen:
+		if failed {
+			b.setState(StateOpen, now)
+			break
+		}
+		b.passed++
+		if b.passed >= b.config.halfOpenProbes {
+			b.setState(StateClosed, now)
+		}
+	}
+	to := b.state
+	b.mu.Unlock()
+
+	b.notify(from, to)
+}
+
+// Cancel releases a request cancelled by the caller without recording an outcome,
+// so a cancelled probe frees its slot instead of closing the circuit.
+func (p *Permit) Cancel() {
+	if !p.finished.CompareAndSwap(false, true) {
+		return
+	}
+	b := p.breaker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if p.epoch == b.epoch && b.state == StateHalfOpen {
+		b.probes--
+	}
+}
+
+// setState moves to 'state' and resets the counters of the new state. Callers hold b.mu.
+func (b *Breaker) setState(state State, now time.Time) {
+	b.state = state
+	b.epoch++
+	b.probes = 0
+	b.passed = 0
+	switch state {
+	case StateOpen:
+		b.openedAt = now
+	case StateClosed:
+		b.window = newWindow(b.config.window)
+	default:
+		// Half-open only resets the probe counters
+	}
+}
+
+// notify calls the state change callback when the state changed.
+func (b *Breaker) notify(from, to State) {
+	if from != to && b.config.onStateChange != nil {
+		b.config.onStateChange(b.name, from, to)
+	}
+}
+
+// Middleware guards every request of a client with this Breaker.
+func (b *Breaker) Middleware() gohungry.Middleware {
+	return middleware(func(*http.Request) *Breaker { return b }, b.config.isFailure)
+}
+
+// HostBreaker keeps a separate Breaker for each request host.
+type HostBreaker struct {
+	config   *config
+	mu       sync.Mutex
+	breakers map[string]*Breaker
+}
+
+// NewHostBreaker creates a HostBreaker whose breakers are configured with 'options'.
+func NewHostBreaker(options ...Option) *HostBreaker {
+	return &HostBreaker{config: newConfig(options), breakers: make(map[string]*Breaker)}
+}
+
+// Breaker returns the Breaker for 'host', creating it on first use.
+func (h *HostBreaker) Breaker(host string) *Breaker {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	breaker, ok := h.breakers[host]
+	if !ok {
+		breaker = newBreaker(host, h.config)
+		h.breakers[host] = breaker
+	}
+	return breaker
+}
+
+// Middleware guards every request of a client with the Breaker of the request host.
+func (h *HostBreaker) Middleware() gohungry.Middleware {
+	return middleware(func(req *http.Request) *Breaker { return h.Breaker(req.URL.Host) }, h.config.isFailure)
+}
+
+// middleware sends each request through the Breaker selected by 'breakerFor'.
+func middleware(breakerFor func(req *http.Request) *Breaker, isFailure FailureFunc) gohungry.Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return gohungry.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			permit, err := breakerFor(req).Allow()
+			if err != nil {
+				return nil, err
+			}
+
+			res, err := next.RoundTrip(req)
+			if errors.Is(err, context.Canceled) || errors.Is(req.Context().Err(), context.Canceled) {
+				permit.Cancel()
+			} else {
+				permit.Done(isFailure(res, err))
+			}
+			return res, err
+		})
+	}
+}
+
+// bucket counts the outcomes within one slice of the rolling window.
+type bucket struct {
+	start    time.Time
+	total    int
+	failures int
+}
+
+// window counts outcomes over a rolling period divided into buckets.
+type window struct {
+	width   time.Duration
+	buckets [windowBuckets]bucket
+}
+
+// newWindow creates an empty window covering 'size'.
+func newWindow(size time.Duration) *window {
+	width := size / windowBuckets
+	if width <= 0 {
+		width = 1
+	}
+	return &window{width: width}
+}
+
+// record counts an outcome at 'now'.
+func (w *window) record(now time.Time, failed bool) {
+	start := now.Truncate(w.width)
+	b := &w.buckets[start.UnixNano()/int64(w.width)%windowBuckets]
+	if !b.start.Equal(start) {
+		*b = bucket{start: start}
+	}
+	b.total++
+	if failed {
+		b.failures++
+	}
+}
+
+// counts returns the outcomes recorded within the window ending at 'now'.
+func (w *window) counts(now time.Time) (total, failures int) {
+	oldest := now.Truncate(w.width).Add(-w.width * (windowBuckets - 1))
+	for _, b := range w.buckets {
+		if !b.start.Before(oldest) {
+			total += b.total
+			failures += b.failures
+		}
+	}
+	return total, failures
+}
@@ -0,0 +1,232 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	gohungry "github.com/guhungry/gohungry/http"
+)
+
+// statusTransport returns responses with the status code stored in 'status'.
+func statusTransport(status *int) http.RoundTripper {
+	return gohungry.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: *status, Body: io.NopCloser(strings.NewReader(""))}, nil
+	})
+}
+
+// send sends a GET request to 'url' through 'transport'.
+func send(transport http.RoundTripper, url string) error {
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	res, err := transport.RoundTrip(req)
+	if err == nil {
+		res.Body.Close()
+	}
+	return err
+}
+
+func TestBreakerLifecycle(t *testing.T) {
+	type change struct{ from, to State }
+	var changes []change
+	breaker := New(
+		WithMinRequests(4),
+		WithFailureRatio(0.5),
+		WithOpenDuration(20*time.Millisecond),
+		WithHalfOpenProbes(2),
+		WithOnStateChange(func(name string, from, to State) { changes = append(changes, change{from, to}) }),
+	)
+	status := http.StatusOK
+	transport := breaker.Middleware()(statusTransport(&status))
+
+	// 2 successes and 2 failures reach the ratio
+	for _, code := range []int{200, 200, 500, 500} {
+		status = code
+		_ = send(transport, "http://example.com")
+	}
+	if breaker.State() != StateOpen {
+		t.Fatalf("Expected open circuit, got %s", breaker.State())
+	}
+	if err := send(transport, "http://example.com"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected ErrCircuitOpen, got %v", err)
+	}
+
+	// Probes close the circuit once the open duration passed
+	time.Sleep(30 * time.Millisecond)
+	status = http.StatusOK
+	for i := 0; i < 2; i++ {
+		if err := send(transport, "http://example.com"); err != nil {
+			t.Fatalf("Expected probe to pass, got %v", err)
+		}
+	}
+	if breaker.State() != StateClosed {
+		t.Fatalf("Expected closed circuit, got %s", breaker.State())
+	}
+
+	expected := []change{{StateClosed, StateOpen}, {StateOpen, StateHalfOpen}, {StateHalfOpen, StateClosed}}
+	if len(changes) != len(expected) {
+		t.Fatalf("Expected changes %v, got %v", expected, changes)
+	}
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Errorf("Expected change %v, got %v", expected[i], changes[i])
+		}
+	}
+}
+
+func TestBreakerHalfOpenFailureReopens(t *testing.T) {
+	breaker := New(WithMinRequests(1), WithOpenDuration(10*time.Millisecond))
+	permit, _ := breaker.Allow()
+	permit.Done(true)
+
+	time.Sleep(20 * time.Millisecond)
+	probe, err := breaker.Allow()
+	if err != nil {
+		t.Fatalf("Expected probe to be allowed, got %v", err)
+	}
+	if _, err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected only one probe while half-open, got %v", err)
+	}
+	probe.Done(true)
+	if breaker.State() != StateOpen {
+		t.Errorf("Expected open circuit after failed probe, got %s", breaker.State())
+	}
+}
+
+func TestBreakerCancelledProbe(t *testing.T) {
+	breaker := New(WithMinRequests(1), WithOpenDuration(10*time.Millisecond))
+	permit, _ := breaker.Allow()
+	permit.Done(true)
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	transport := breaker.Middleware()(gohungry.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		cancel()
+		return nil, req.Context().Err()
+	}))
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.com", nil)
+	if _, err := transport.RoundTrip(req); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context canceled, got %v", err)
+	}
+
+	if breaker.State() != StateHalfOpen {
+		t.Errorf("Expected half-open circuit after a cancelled probe, got %s", breaker.State())
+	}
+	if _, err := breaker.Allow(); err != nil {
+		t.Errorf("Expected the cancelled probe to free its slot, got %v", err)
+	}
+}
+
+func TestBreakerPermitCancel(t *testing.T) {
+	breaker := New(WithMinRequests(1), WithOpenDuration(10*time.Millisecond))
+	permit, _ := breaker.Allow()
+	permit.Done(true)
+	time.Sleep(20 * time.Millisecond)
+
+	probe, err := breaker.Allow()
+	if err != nil {
+		t.Fatalf("Expected probe to be allowed, got %v", err)
+	}
+	probe.Cancel()
+	probe.Done(false)
+	if breaker.State() != StateHalfOpen {
+		t.Errorf("Expected half-open circuit after a cancelled probe, got %s", breaker.State())
+	}
+	if _, err := breaker.Allow(); err != nil {
+		t.Errorf("Expected the cancelled probe to free its slot, got %v", err)
+	}
+}
+
+func TestBreakerIgnoresEarlierState(t *testing.T) {
+	breaker := New(WithMinRequests(1), WithOpenDuration(10*time.Millisecond))
+	late, _ := breaker.Allow()
+	permit, _ := breaker.Allow()
+	permit.Done(true)
+	time.Sleep(20 * time.Millisecond)
+
+	probe, err := breaker.Allow()
+	if err != nil {
+		t.Fatalf("Expected probe to be allowed, got %v", err)
+	}
+	// A request let through while closed finishes successfully while half-open
+	late.Done(false)
+	if breaker.State() != StateHalfOpen {
+		t.Errorf("Expected the late outcome to be ignored, got %s", breaker.State())
+	}
+	probe.Done(false)
+	if breaker.State() != StateClosed {
+		t.Errorf("Expected closed circuit after the probe, got %s", breaker.State())
+	}
+}
+
+func TestBreakerBelowMinRequests(t *testing.T) {
+	breaker := New(WithMinRequests(3))
+	for i := 0; i < 2; i++ {
+		permit, _ := breaker.Allow()
+		permit.Done(true)
+	}
+	if breaker.State() != StateClosed {
+		t.Errorf("Expected closed circuit below minimum requests, got %s", breaker.State())
+	}
+}
+
+func TestHostBreakerSeparatesHosts(t *testing.T) {
+	var opened []string
+	breakers := NewHostBreaker(WithMinRequests(1), WithOnStateChange(func(name string, from, to State) {
+		opened = append(opened, name)
+	}))
+	status := http.StatusBadGateway
+	transport := breakers.Middleware()(statusTransport(&status))
+
+	_ = send(transport, "http://a.example.com")
+	if err := send(transport, "http://a.example.com"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected ErrCircuitOpen for a.example.com, got %v", err)
+	}
+	status = http.StatusOK
+	if err := send(transport, "http://b.example.com"); err != nil {
+		t.Errorf("Expected b.example.com to be unaffected, got %v", err)
+	}
+	if len(opened) != 1 || opened[0] != "a.example.com" {
+		t.Errorf("Expected only a.example.com to change state, got %v", opened)
+	}
+}
+
+func TestIsFailure(t *testing.T) {
+	tests := []struct {
+		name     string
+		res      *http.Response
+		err      error
+		expected bool
+	}{
+		{name: "Success", res: &http.Response{StatusCode: 200}, expected: false},
+		{name: "Client Error", res: &http.Response{StatusCode: 404}, expected: false},
+		{name: "Server Error", res: &http.Response{StatusCode: 503}, expected: true},
+		{name: "Transport Error", err: errors.New("connection refused"), expected: true},
+		{name: "Cancelled By Caller", err: context.Canceled, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := isFailure(tt.res, tt.err); result != tt.expected {
+				t.Errorf("isFailure() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWindowDropsOldBuckets(t *testing.T) {
+	w := newWindow(100 * time.Millisecond)
+	now := time.Unix(0, 0).Add(time.Hour)
+	w.record(now, true)
+	w.record(now.Add(50*time.Millisecond), false)
+
+	if total, failures := w.counts(now.Add(50 * time.Millisecond)); total != 2 || failures != 1 {
+		t.Errorf("Expected 2 requests and 1 failure, got %d and %d", total, failures)
+	}
+	if total, failures := w.counts(now.Add(120 * time.Millisecond)); total != 1 || failures != 0 {
+		t.Errorf("Expected old bucket to expire, got %d requests and %d failures", total, failures)
+	}
+}
@@ -1,6 +1,23 @@
 package http
 
-import "net/http"
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Default client settings used by NewClient and ResetHTTPClient
+const (
+	DefaultTimeout               = 30 * time.Second // Overall time limit for a request, including reading the body
+	DefaultDialTimeout           = 10 * time.Second // Time limit for establishing a TCP connection
+	DefaultKeepAlive             = 30 * time.Second // Interval between TCP keep-alive probes
+	DefaultTLSHandshakeTimeout   = 10 * time.Second // Time limit for the TLS handshake
+	DefaultResponseHeaderTimeout = 20 * time.Second // Time limit for the response headers after the request is written
+	DefaultIdleConnTimeout       = 90 * time.Second // Time an idle keep-alive connection stays in the pool
+	DefaultMaxIdleConnsPerHost   = 32               // Idle keep-alive connections kept per host
+	DefaultMaxConnsPerHost       = 0                // Connections per host, 0 means no limit
+)
 
 // HTTPClient is an interface that wraps the Do method for making HTTP requests.
 // This allows for easy replacement with mocks during unit testing.
@@ -17,11 +34,173 @@ func SetHTTPClient(client HTTPClient) {
 	Client = client
 }
 
-// ResetHTTPClient restores the default HTTP client.
+// ResetHTTPClient restores the default HTTP client, configured with the default timeouts and pool sizes.
 func ResetHTTPClient() {
-	Client = &http.Client{}
+	// The default configuration loads no files, so it cannot fail
+	client, _ := NewClient()
+	Client = client
 }
 
 func init() {
 	ResetHTTPClient()
 }
+
+// clientConfig holds the settings collected by ClientOption for NewClient.
+type clientConfig struct {
+	certFile              string
+	keyFile               string
+	certPEM               []byte
+	keyPEM                []byte
+	rootCAFiles           []string
+	rootCAs               [][]byte
+	minTLSVersion         uint16
+	reloadInterval        time.Duration
+	timeout               time.Duration
+	dialTimeout           time.Duration
+	tlsHandshakeTimeout   time.Duration
+	responseHeaderTimeout time.Duration
+	idleConnTimeout       time.Duration
+	maxIdleConnsPerHost   int
+	maxConnsPerHost       int
+	middlewares           []Middleware
+}
+
+// ClientOption modifies the configuration used by NewClient.
+type ClientOption func(c *clientConfig)
+
+// NewClient creates an HTTP client configured with 'options'.
+// Use SetHTTPClient to make it the default client.
+func NewClient(options ...ClientOption) (*http.Client, error) {
+	config := &clientConfig{
+		minTLSVersion:         tls.VersionTLS12,
+		timeout:               DefaultTimeout,
+		dialTimeout:           DefaultDialTimeout,
+		tlsHandshakeTimeout:   DefaultTLSHandshakeTimeout,
+		responseHeaderTimeout: DefaultResponseHeaderTimeout,
+		idleConnTimeout:       DefaultIdleConnTimeout,
+		maxIdleConnsPerHost:   DefaultMaxIdleConnsPerHost,
+		maxConnsPerHost:       DefaultMaxConnsPerHost,
+	}
+	for _, option := range options {
+		option(config)
+	}
+
+	tlsConfig, err := newTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: config.dialTimeout, KeepAlive: DefaultKeepAlive}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	transport.TLSClientConfig = tlsConfig
+	transport.TLSHandshakeTimeout = config.tlsHandshakeTimeout
+	transport.ResponseHeaderTimeout = config.responseHeaderTimeout
+	transport.IdleConnTimeout = config.idleConnTimeout
+	transport.MaxIdleConns = 0 // Bounded by MaxIdleConnsPerHost instead
+	transport.MaxIdleConnsPerHost = config.maxIdleConnsPerHost
+	transport.MaxConnsPerHost = config.maxConnsPerHost
+	return &http.Client{Transport: chainMiddlewares(transport, config.middlewares), Timeout: config.timeout}, nil
+}
+
+// WithClientCertificateFiles loads the client certificate and key from PEM files for mutual TLS.
+func WithClientCertificateFiles(certFile, keyFile string) ClientOption {
+	return func(c *clientConfig) {
+		c.certFile = certFile
+		c.keyFile = keyFile
+	}
+}
+
+// WithClientCertificate uses the PEM encoded client certificate and key for mutual TLS.
+func WithClientCertificate(certPEM, keyPEM []byte) ClientOption {
+	return func(c *clientConfig) {
+		c.certPEM = certPEM
+		c.keyPEM = keyPEM
+	}
+}
+
+// WithRootCAFiles trusts the PEM encoded CA certificates in 'files' in addition to the system roots.
+func WithRootCAFiles(files ...string) ClientOption {
+	return func(c *clientConfig) {
+		c.rootCAFiles = append(c.rootCAFiles, files...)
+	}
+}
+
+// WithRootCAs trusts the PEM encoded CA certificates in addition to the system roots.
+func WithRootCAs(certPEMs ...[]byte) ClientOption {
+	return func(c *clientConfig) {
+		c.rootCAs = append(c.rootCAs, certPEMs...)
+	}
+}
+
+// WithMinTLSVersion sets the minimum TLS version, e.g. tls.VersionTLS13. Defaults to TLS 1.2.
+func WithMinTLSVersion(version uint16) ClientOption {
+	return func(c *clientConfig) {
+		c.minTLSVersion = version
+	}
+}
+
+// WithCertificateReload checks the files from WithClientCertificateFiles for changes
+// at most once per 'interval' and reloads the certificate when they are modified.
+func WithCertificateReload(interval time.Duration) ClientOption {
+	return func(c *clientConfig) {
+		c.reloadInterval = interval
+	}
+}
+
+// WithTimeout sets the overall time limit for a request, including reading the body. Zero means no limit.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *clientConfig) {
+		c.timeout = timeout
+	}
+}
+
+// WithDialTimeout sets the time limit for establishing a TCP connection.
+func WithDialTimeout(timeout time.Duration) ClientOption {
+	return func(c *clientConfig) {
+		c.dialTimeout = timeout
+	}
+}
+
+// WithTLSHandshakeTimeout sets the time limit for the TLS handshake.
+func WithTLSHandshakeTimeout(timeout time.Duration) ClientOption {
+	return func(c *clientConfig) {
+		c.tlsHandshakeTimeout = timeout
+	}
+}
+
+// WithResponseHeaderTimeout sets the time limit for reading the response headers after the request is written.
+func WithResponseHeaderTimeout(timeout time.Duration) ClientOption {
+	return func(c *clientConfig) {
+		c.responseHeaderTimeout = timeout
+	}
+}
+
+// WithIdleConnTimeout sets how long an idle keep-alive connection stays in the pool.
+func WithIdleConnTimeout(timeout time.Duration) ClientOption {
+	return func(c *clientConfig) {
+		c.idleConnTimeout = timeout
+	}
+}
+
+// WithMaxIdleConnsPerHost sets the number of idle keep-alive connections kept per host.
+func WithMaxIdleConnsPerHost(n int) ClientOption {
+	return func(c *clientConfig) {
+		c.maxIdleConnsPerHost = n
+	}
+}
+
+// WithMaxConnsPerHost limits the connections per host, including those in use. Zero means no limit.
+func WithMaxConnsPerHost(n int) ClientOption {
+	return func(c *clientConfig) {
+		c.maxConnsPerHost = n
+	}
+}
+
+// WithMiddleware wraps the client transport with 'middlewares'. The first middleware is the outermost,
+// so it sees each request first and each response last.
+func WithMiddleware(middlewares ...Middleware) ClientOption {
+	return func(c *clientConfig) {
+		c.middlewares = append(c.middlewares, middlewares...)
+	}
+}
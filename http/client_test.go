@@ -0,0 +1,93 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	nethttptest "net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNewClientDefaults(t *testing.T) {
+	httpClient, err := NewClient()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	transport := httpClient.Transport.(*http.Transport)
+
+	if httpClient.Timeout != DefaultTimeout {
+		t.Errorf("Expected timeout %v, got %v", DefaultTimeout, httpClient.Timeout)
+	}
+	if transport.TLSHandshakeTimeout != DefaultTLSHandshakeTimeout {
+		t.Errorf("Expected TLS handshake timeout %v, got %v", DefaultTLSHandshakeTimeout, transport.TLSHandshakeTimeout)
+	}
+	if transport.ResponseHeaderTimeout != DefaultResponseHeaderTimeout {
+		t.Errorf("Expected response header timeout %v, got %v", DefaultResponseHeaderTimeout, transport.ResponseHeaderTimeout)
+	}
+	if transport.IdleConnTimeout != DefaultIdleConnTimeout {
+		t.Errorf("Expected idle connection timeout %v, got %v", DefaultIdleConnTimeout, transport.IdleConnTimeout)
+	}
+	if transport.MaxIdleConnsPerHost != DefaultMaxIdleConnsPerHost {
+		t.Errorf("Expected max idle connections per host %d, got %d", DefaultMaxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+	}
+}
+
+func TestNewClientPoolAndTimeoutOptions(t *testing.T) {
+	httpClient, err := NewClient(
+		WithTimeout(time.Minute),
+		WithDialTimeout(time.Second),
+		WithTLSHandshakeTimeout(2*time.Second),
+		WithResponseHeaderTimeout(3*time.Second),
+		WithIdleConnTimeout(4*time.Second),
+		WithMaxIdleConnsPerHost(100),
+		WithMaxConnsPerHost(200),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	transport := httpClient.Transport.(*http.Transport)
+
+	if httpClient.Timeout != time.Minute {
+		t.Errorf("Expected timeout 1m, got %v", httpClient.Timeout)
+	}
+	if transport.TLSHandshakeTimeout != 2*time.Second {
+		t.Errorf("Expected TLS handshake timeout 2s, got %v", transport.TLSHandshakeTimeout)
+	}
+	if transport.ResponseHeaderTimeout != 3*time.Second {
+		t.Errorf("Expected response header timeout 3s, got %v", transport.ResponseHeaderTimeout)
+	}
+	if transport.IdleConnTimeout != 4*time.Second {
+		t.Errorf("Expected idle connection timeout 4s, got %v", transport.IdleConnTimeout)
+	}
+	if transport.MaxIdleConnsPerHost != 100 || transport.MaxConnsPerHost != 200 {
+		t.Errorf("Expected 100 idle and 200 connections per host, got %d and %d", transport.MaxIdleConnsPerHost, transport.MaxConnsPerHost)
+	}
+}
+
+func TestNewClientTimeout(t *testing.T) {
+	server := nethttptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	httpClient, _ := NewClient(WithTimeout(50 * time.Millisecond))
+	_, err := httpClient.Get(server.URL)
+
+	var timeoutErr interface{ Timeout() bool }
+	if !errors.As(err, &timeoutErr) || !timeoutErr.Timeout() {
+		t.Errorf("Expected timeout error, got %v", err)
+	}
+}
+
+func TestResetHTTPClient(t *testing.T) {
+	SetHTTPClient(&http.Client{})
+	ResetHTTPClient()
+
+	httpClient, ok := Client.(*http.Client)
+	if !ok || httpClient.Timeout != DefaultTimeout {
+		t.Errorf("Expected default client with timeout %v, got %+v", DefaultTimeout, Client)
+	}
+}
@@ -0,0 +1,194 @@
+// Package coalesce merges concurrent identical GET and HEAD requests into a single upstream call.
+// Requests are identical when they share the method, URL and the headers that select the response
+// or identify the caller, such as Accept and Authorization, including the API key header configured
+// with gohungry's WithAuthAPIKey. Every caller receives its own copy of
+// the response, so each decodes its own result. Attach a Group with gohungry's WithMiddleware
+// client option.
+package coalesce
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	gohungry "github.com/guhungry/gohungry/http"
+)
+
+// Default group settings
+const (
+	DefaultTimeout      = gohungry.DefaultTimeout          // Time limit of a shared request, which no single caller can cancel
+	DefaultMaxBodyBytes = gohungry.DefaultMaxResponseBytes // Largest response body buffered for the callers
+)
+
+// DefaultKeyHeaders are the request headers that are part of the key by default,
+// in addition to gohungry's CredentialHeaders.
+var DefaultKeyHeaders = []string{
+	"Accept",
+	"Accept-Encoding",
+	"Accept-Language",
+	"X-API-Key",
+}
+
+// Group merges concurrent identical requests.
+type Group struct {
+	keyHeaders   []string
+	timeout      time.Duration
+	maxBodyBytes int64
+	mu           sync.Mutex
+	calls        map[string]*call
+}
+
+// call is an upstream request shared by every caller with the same key.
+type call struct {
+	done chan struct{}
+	res  *http.Response
+	body []byte
+	err  error
+}
+
+// Option modifies a Group.
+type Option func(g *Group)
+
+// WithKeyHeaders adds request headers to the key. Headers identifying the caller must be added
+// when they are set without gohungry's auth options, e.g. an API key set with WithHeader.
+func WithKeyHeaders(names ...string) Option {
+	return func(g *Group) {
+		g.keyHeaders = append(g.keyHeaders, names...)
+	}
+}
+
+// WithTimeout sets the time limit of a shared request instead of DefaultTimeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(g *Group) {
+		g.timeout = timeout
+	}
+}
+
+// WithMaxBodyBytes sets the largest response body buffered for the callers instead of DefaultMaxBodyBytes.
+// A larger body fails the shared request with gohungry's ResponseTooLargeError.
+func WithMaxBodyBytes(n int64) Option {
+	return func(g *Group) {
+		g.maxBodyBytes = n
+	}
+}
+
+// New creates a Group configured with 'options'.
+func New(options ...Option) *Group {
+	result := &Group{
+		keyHeaders:   slices.Clone(DefaultKeyHeaders),
+		timeout:      DefaultTimeout,
+		maxBodyBytes: DefaultMaxBodyBytes,
+		calls:        make(map[string]*call),
+	}
+	for _, option := range options {
+		option(result)
+	}
+	for i, name := range result.keyHeaders {
+		result.keyHeaders[i] = http.CanonicalHeaderKey(name)
+	}
+	slices.Sort(result.keyHeaders)
+	result.keyHeaders = slices.Compact(result.keyHeaders)
+	return result
+}
+
+// Middleware merges the concurrent identical requests of a client.
+func (g *Group) Middleware() gohungry.Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return gohungry.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Method != http.MethodGet && req.Method != http.MethodHead {
+				return next.RoundTrip(req)
+			}
+			return g.roundTrip(next, req)
+		})
+	}
+}
+
+// roundTrip joins the call in flight for the key of 'req', or starts one.
+func (g *Group) roundTrip(next http.RoundTripper, req *http.Request) (*http.Response, error) {
+	key := g.key(req)
+
+	g.mu.Lock()
+	c, ok := g.calls[key]
+	if !ok {
+		c = &call{done: make(chan struct{})}
+		g.calls[key] = c
+		go g.do(next, req, key, c)
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.response(req), nil
+}
+
+// do sends the shared request and reads the whole response, up to the body limit, for the callers.
+// It is detached from the cancellation of the first caller, including the Cancel channel
+// used by http.Client timeouts, so one caller giving up does not fail the others.
+// Its own time limit keeps a hung upstream from holding the call forever.
+func (g *Group) do(next http.RoundTripper, req *http.Request, key string, c *call) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(req.Context()), g.timeout)
+	defer func() {
+		cancel()
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(c.done)
+	}()
+
+	shared := req.Clone(ctx)
+	shared.Cancel = nil // Set by http.Client to enforce its timeout
+	res, err := next.RoundTrip(shared)
+	if err != nil {
+		c.err = err
+		return
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, g.maxBodyBytes+1))
+	if err == nil && int64(len(body)) > g.maxBodyBytes {
+		err = &gohungry.ResponseTooLargeError{Limit: g.maxBodyBytes, Read: int64(len(body))}
+	}
+	c.body, c.err = body, err
+	c.res = res
+}
+
+// response returns a copy of the shared response for 'req' with its own body reader.
+func (c *call) response(req *http.Request) *http.Response {
+	result := *c.res
+	result.Header = c.res.Header.Clone()
+	result.Trailer = c.res.Trailer.Clone()
+	result.Body = io.NopCloser(bytes.NewReader(c.body))
+	result.ContentLength = int64(len(c.body))
+	result.Request = req
+	return &result
+}
+
+// key identifies requests that can share a response.
+func (g *Group) key(req *http.Request) string {
+	names := append(slices.Clone(g.keyHeaders), gohungry.CredentialHeaders(req)...)
+	slices.Sort(names)
+	names = slices.Compact(names)
+
+	var builder strings.Builder
+	builder.WriteString(req.Method)
+	builder.WriteString(" ")
+	builder.WriteString(req.URL.String())
+	for _, name := range names {
+		builder.WriteString("\n")
+		builder.WriteString(name)
+		builder.WriteString(": ")
+		builder.WriteString(strings.Join(req.Header.Values(name), ", "))
+	}
+	return builder.String()
+}
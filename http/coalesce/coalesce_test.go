@@ -0,0 +1,242 @@
+package coalesce
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	nethttptest "net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gohungry "github.com/guhungry/gohungry/http"
+	"github.com/guhungry/gohungry/http/json"
+)
+
+// Config is the decoded test response.
+type Config struct {
+	Values []string `json:"values"`
+}
+
+// slowUpstream counts requests and answers once 'release' is closed.
+func slowUpstream(calls *atomic.Int32, release chan struct{}) http.RoundTripper {
+	return gohungry.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		calls.Add(1)
+		<-release
+		if err := req.Context().Err(); err != nil {
+			return nil, err
+		}
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(`{"values":["a","b"]}`))}, nil
+	})
+}
+
+func TestGroupMergesConcurrentRequests(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	gohungry.SetHTTPClient(&http.Client{Transport: New().Middleware()(slowUpstream(&calls, release))})
+	defer gohungry.ResetHTTPClient()
+
+	const callers = 20
+	results := make([]*Config, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			result, err := json.Get[Config]("http://example.com/config")
+			if err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			results[i] = result
+		}(i)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("Expected 1 upstream call, got %d", calls.Load())
+	}
+
+	// Each caller owns its result
+	results[0].Values[0] = "changed"
+	for i := 1; i < callers; i++ {
+		if results[i] == nil || results[i].Values[0] != "a" {
+			t.Fatalf("Expected caller %d to have its own copy, got %+v", i, results[i])
+		}
+	}
+}
+
+func TestGroupCancelledCallerDoesNotCancelSharedCall(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	transport := New().Middleware()(slowUpstream(&calls, release))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.com/config", nil)
+	firstErr := make(chan error)
+	go func() {
+		_, err := transport.RoundTrip(first)
+		firstErr <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	second, _ := http.NewRequest(http.MethodGet, "http://example.com/config", nil)
+	secondRes := make(chan *http.Response)
+	go func() {
+		res, err := transport.RoundTrip(second)
+		if err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+		secondRes <- res
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	cancel()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected first caller to be cancelled, got %v", err)
+	}
+	close(release)
+	if res := <-secondRes; res == nil || res.StatusCode != http.StatusOK {
+		t.Errorf("Expected second caller to get the response, got %+v", res)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Expected 1 upstream call, got %d", calls.Load())
+	}
+}
+
+func TestGroupClientTimeoutDoesNotCancelSharedCall(t *testing.T) {
+	server := nethttptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		_, _ = w.Write([]byte(`{"values":["a","b"]}`))
+	}))
+	defer server.Close()
+
+	group := New()
+	impatient, _ := gohungry.NewClient(gohungry.WithTimeout(100*time.Millisecond), gohungry.WithMiddleware(group.Middleware()))
+	patient, _ := gohungry.NewClient(gohungry.WithMiddleware(group.Middleware()))
+
+	firstErr := make(chan error)
+	go func() {
+		_, err := impatient.Get(server.URL)
+		firstErr <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	res, err := patient.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected the second caller to get the response, got %v", err)
+	}
+	res.Body.Close()
+	if err := <-firstErr; err == nil {
+		t.Error("Expected the first caller to time out, got nil")
+	}
+}
+
+func TestGroupSharedCallTimeout(t *testing.T) {
+	hung := gohungry.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	})
+	group := New(WithTimeout(20 * time.Millisecond))
+	transport := group.Middleware()(hung)
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/config", nil)
+	if _, err := transport.RoundTrip(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+	group.mu.Lock()
+	defer group.mu.Unlock()
+	if len(group.calls) != 0 {
+		t.Errorf("Expected the call to be removed, got %d calls", len(group.calls))
+	}
+}
+
+func TestGroupBodyTooLarge(t *testing.T) {
+	large := gohungry.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(strings.Repeat("a", 11)))}, nil
+	})
+	transport := New(WithMaxBodyBytes(10)).Middleware()(large)
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/config", nil)
+	if _, err := transport.RoundTrip(req); !errors.Is(err, gohungry.ErrResponseTooLarge) {
+		t.Errorf("Expected ErrResponseTooLarge, got %v", err)
+	}
+}
+
+func TestGroupKey(t *testing.T) {
+	group := New(WithKeyHeaders("x-tenant"))
+	newRequest := func(method, url string, header http.Header) *http.Request {
+		req, _ := http.NewRequest(method, url, nil)
+		req.Header = header
+		return req
+	}
+	base := newRequest(http.MethodGet, "http://example.com/a", http.Header{"Authorization": {"Bearer one"}, "X-Tenant": {"t1"}})
+
+	tests := []struct {
+		name     string
+		req      *http.Request
+		expected bool
+	}{
+		{name: "Same Request", req: newRequest(http.MethodGet, "http://example.com/a", http.Header{"Authorization": {"Bearer one"}, "X-Tenant": {"t1"}, "X-Trace": {"1"}}), expected: true},
+		{name: "Other URL", req: newRequest(http.MethodGet, "http://example.com/b", http.Header{"Authorization": {"Bearer one"}, "X-Tenant": {"t1"}}), expected: false},
+		{name: "Other Method", req: newRequest(http.MethodHead, "http://example.com/a", http.Header{"Authorization": {"Bearer one"}, "X-Tenant": {"t1"}}), expected: false},
+		{name: "Other Identity", req: newRequest(http.MethodGet, "http://example.com/a", http.Header{"Authorization": {"Bearer two"}, "X-Tenant": {"t1"}}), expected: false},
+		{name: "Other Custom Header", req: newRequest(http.MethodGet, "http://example.com/a", http.Header{"Authorization": {"Bearer one"}, "X-Tenant": {"t2"}}), expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := group.key(tt.req) == group.key(base); result != tt.expected {
+				t.Errorf("Expected same key %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestGroupSeparatesAPIKeys(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	gohungry.SetHTTPClient(&http.Client{Transport: New().Middleware()(slowUpstream(&calls, release))})
+	defer gohungry.ResetHTTPClient()
+
+	var wg sync.WaitGroup
+	for _, key := range []string{"one", "two"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			if _, err := json.Get[Config]("http://example.com/config", gohungry.WithAuthAPIKey[Config](gohungry.APIKeyInHeader, "Api-Key", key)); err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		}(key)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 2 {
+		t.Errorf("Expected 1 upstream call per API key, got %d", calls.Load())
+	}
+}
+
+func TestGroupPassesThroughUnsafeMethods(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	close(release)
+	transport := New().Middleware()(slowUpstream(&calls, release))
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest(http.MethodPost, "http://example.com/config", strings.NewReader("{}"))
+		res, err := transport.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		res.Body.Close()
+	}
+	if calls.Load() != 2 {
+		t.Errorf("Expected every POST to reach the upstream, got %d calls", calls.Load())
+	}
+}
@@ -19,11 +19,33 @@ type AuthType string
 const (
 	AuthTypeBasic  AuthType = "Basic"  // Basic authentication as per RFC 7617
 	AuthTypeBearer AuthType = "Bearer" // Bearer token authentication as per RFC 6750
+	AuthTypeAPIKey AuthType = "APIKey" // API key authentication sent in a header or query parameter
+)
+
+// APIKeyLocation represents where an API key is placed in HTTP requests
+type APIKeyLocation string
+
+// API key location constants
+const (
+	APIKeyInHeader APIKeyLocation = "header" // API key sent as a request header, e.g. X-API-Key
+	APIKeyInQuery  APIKeyLocation = "query"  // API key sent as a URL query parameter, e.g. ?api_key=
 )
 
 // Common HTTP header constants for setting request headers
 const (
-	HeaderAuthorization = "Authorization" // Header for authorization credentials
-	HeaderContentType   = "Content-Type"  // Header indicating the media type of the resource
-	HeaderAccept        = "Accept"        // Header indicating the media types acceptable for the response
+	HeaderAuthorization      = "Authorization"       // Header for authorization credentials
+	HeaderContentType        = "Content-Type"        // Header indicating the media type of the resource
+	HeaderAccept             = "Accept"              // Header indicating the media types acceptable for the response
+	HeaderCacheStatus        = "X-Gohungry-Cache"    // Header set by the cache middleware with the CacheStatus of the response
+	HeaderAcceptEncoding     = "Accept-Encoding"     // Header indicating the content encodings acceptable for the response
+	HeaderContentEncoding    = "Content-Encoding"    // Header indicating the content encodings applied to the body
+	HeaderIdempotencyKey     = "Idempotency-Key"     // Header marking a request as safe to send more than once
+	HeaderCookie             = "Cookie"              // Header carrying the cookies of the caller
+	HeaderProxyAuthorization = "Proxy-Authorization" // Header for proxy authorization credentials
+)
+
+// Rate limit header constants commonly sent by APIs with request quotas
+const (
+	HeaderRateLimitRemaining = "X-RateLimit-Remaining" // Header with the number of requests left in the current window
+	HeaderRateLimitReset     = "X-RateLimit-Reset"     // Header with the time the window resets, in Unix or delta seconds
 )
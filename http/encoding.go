@@ -0,0 +1,283 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// ErrUnsupportedContentEncoding is returned for a response body in an encoding without a registered decoder.
+var ErrUnsupportedContentEncoding = errors.New("gohungry: unsupported content encoding")
+
+// Decoder wraps a compressed response body to read it decoded.
+type Decoder func(r io.Reader) (io.ReadCloser, error)
+
+// Encoder wraps a writer to compress the request body written to it.
+type Encoder func(w io.Writer) (io.WriteCloser, error)
+
+// encoders holds the request body encoders by content encoding.
+var encoders = struct {
+	mu       sync.RWMutex
+	encoders map[string]Encoder
+}{encoders: make(map[string]Encoder)}
+
+// RegisterEncoder registers 'encoder' for the content encoding 'encoding', replacing any previous one.
+func RegisterEncoder(encoding string, encoder Encoder) {
+	encoders.mu.Lock()
+	defer encoders.mu.Unlock()
+
+	encoders.encoders[strings.ToLower(encoding)] = encoder
+}
+
+// encoder returns the encoder for 'encoding'.
+func encoder(encoding string) (Encoder, bool) {
+	encoders.mu.RLock()
+	defer encoders.mu.RUnlock()
+
+	result, ok := encoders.encoders[strings.ToLower(encoding)]
+	return result, ok
+}
+
+// decoderRegistry holds the decoders by content encoding, in order of preference.
+type decoderRegistry struct {
+	mu        sync.RWMutex
+	encodings []string
+	decoders  map[string]Decoder
+}
+
+// decoders is the registry used by DoRequest to advertise and decode response encodings.
+var decoders = &decoderRegistry{decoders: make(map[string]Decoder)}
+
+// RegisterDecoder registers 'decoder' for the content encoding 'encoding', replacing any previous one.
+// Registered encodings are advertised in the Accept-Encoding header of every request.
+func RegisterDecoder(encoding string, decoder Decoder) {
+	decoders.mu.Lock()
+	defer decoders.mu.Unlock()
+
+	encoding = strings.ToLower(encoding)
+	if _, ok := decoders.decoders[encoding]; !ok {
+		decoders.encodings = append(decoders.encodings, encoding)
+	}
+	decoders.decoders[encoding] = decoder
+}
+
+// acceptEncoding returns the Accept-Encoding header value listing the registered encodings.
+func (r *decoderRegistry) acceptEncoding() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return strings.Join(r.encodings, ", ")
+}
+
+// decoder returns the decoder for 'encoding'.
+func (r *decoderRegistry) decoder(encoding string) (Decoder, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	decoder, ok := r.decoders[strings.ToLower(encoding)]
+	return decoder, ok
+}
+
+func init() {
+	RegisterDecoder("zstd", decodeZstd)
+	RegisterDecoder("br", decodeBrotli)
+	RegisterDecoder("gzip", decodeGzip)
+	RegisterDecoder("deflate", decodeDeflate)
+
+	RegisterEncoder("zstd", func(w io.Writer) (io.WriteCloser, error) { return zstd.NewWriter(w, zstd.WithEncoderConcurrency(1)) })
+	RegisterEncoder("br", func(w io.Writer) (io.WriteCloser, error) { return brotli.NewWriter(w), nil })
+	RegisterEncoder("gzip", func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil })
+	RegisterEncoder("deflate", func(w io.Writer) (io.WriteCloser, error) { return zlib.NewWriter(w), nil })
+}
+
+// decodeGzip decodes the gzip content encoding.
+func decodeGzip(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+// decodeDeflate decodes the deflate content encoding. It is zlib wrapped as per RFC 9110,
+// but some servers send raw deflate data, so that is accepted as well.
+func decodeDeflate(r io.Reader) (io.ReadCloser, error) {
+	buffered := bufio.NewReader(r)
+	header, err := buffered.Peek(2)
+	if err != nil {
+		return nil, err
+	}
+	// zlib header: compression method 8 and a check sum over the first two bytes
+	if header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(buffered)
+	}
+	return flate.NewReader(buffered), nil
+}
+
+// decodeBrotli decodes the br content encoding.
+func decodeBrotli(r io.Reader) (io.ReadCloser, error) {
+	return io.NopCloser(brotli.NewReader(r)), nil
+}
+
+// decodeZstd decodes the zstd content encoding.
+func decodeZstd(r io.Reader) (io.ReadCloser, error) {
+	decoder, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return decoder.IOReadCloser(), nil
+}
+
+// compressBody compresses 'body' with 'encoding' when it holds at least 'minSize' bytes,
+// and reports whether it did. Buffered bodies are compressed in memory, streaming bodies
+// are compressed while they are sent.
+func compressBody(body io.Reader, encoding string, minSize int) (io.Reader, bool, error) {
+	if encoding == "" {
+		return body, false, nil
+	}
+	newEncoder, ok := encoder(encoding)
+	if !ok {
+		return nil, false, fmt.Errorf("%w: %s", ErrUnsupportedContentEncoding, encoding)
+	}
+
+	if buffered, ok := body.(*bytes.Reader); ok {
+		if buffered.Len() < minSize {
+			return body, false, nil
+		}
+		var result bytes.Buffer
+		if err := encode(&result, buffered, newEncoder); err != nil {
+			return nil, false, err
+		}
+		return bytes.NewReader(result.Bytes()), true, nil
+	}
+
+	// Streaming bodies are only compressed once they reach 'minSize', read as they arrive
+	if minSize > 0 {
+		head, err := io.ReadAll(io.LimitReader(body, int64(minSize)))
+		if err != nil {
+			return nil, false, err
+		}
+		if len(head) < minSize {
+			return bytes.NewReader(head), false, nil
+		}
+		body = io.MultiReader(bytes.NewReader(head), body)
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(encode(writer, body, newEncoder))
+	}()
+	return reader, true, nil
+}
+
+// encode writes 'body' compressed by the encoder from 'newEncoder' to 'w'.
+func encode(w io.Writer, body io.Reader, newEncoder Encoder) error {
+	writer, err := newEncoder(w)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(writer, body); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+
+// setAcceptEncoding advertises the registered encodings unless the caller set Accept-Encoding.
+func setAcceptEncoding(req *http.Request) {
+	if req.Header.Get(HeaderAcceptEncoding) == "" {
+		req.Header.Set(HeaderAcceptEncoding, decoders.acceptEncoding())
+	}
+}
+
+// decodeBody returns the response body decoded from every encoding listed in Content-Encoding.
+func decodeBody(res *http.Response) (io.ReadCloser, error) {
+	var encodings []string
+	for _, value := range res.Header.Values(HeaderContentEncoding) {
+		for _, encoding := range strings.Split(value, ",") {
+			if encoding = strings.TrimSpace(encoding); encoding != "" && !strings.EqualFold(encoding, "identity") {
+				encodings = append(encodings, encoding)
+			}
+		}
+	}
+	if len(encodings) == 0 || hasNoBody(res) {
+		return res.Body, nil
+	}
+
+	result := &decodedBody{Reader: res.Body}
+	// Encodings are listed in the order they were applied
+	for i := len(encodings) - 1; i >= 0; i-- {
+		decoder, ok := decoders.decoder(encodings[i])
+		if !ok {
+			result.Close()
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedContentEncoding, encodings[i])
+		}
+		reader := &lazyDecoder{decoder: decoder, source: result.Reader}
+		result.Reader = reader
+		result.closers = append(result.closers, reader)
+	}
+	return result, nil
+}
+
+// decodedBody reads a decoded response body and closes every decoder.
+type decodedBody struct {
+	io.Reader
+	closers []io.Closer
+}
+
+// Close closes the decoders, innermost first.
+func (b *decodedBody) Close() error {
+	var errs []error
+	for i := len(b.closers) - 1; i >= 0; i-- {
+		errs = append(errs, b.closers[i].Close())
+	}
+	return errors.Join(errs...)
+}
+
+// hasNoBody reports whether 'res' cannot have a body, whatever its Content-Encoding says.
+func hasNoBody(res *http.Response) bool {
+	if res.StatusCode == http.StatusNoContent || res.StatusCode == http.StatusNotModified {
+		return true
+	}
+	return res.Request != nil && res.Request.Method == http.MethodHead
+}
+
+// lazyDecoder creates its decoder on the first Read. Decoders read a header when they are created,
+// which fails on an empty body, so an empty body is decoded as empty instead.
+type lazyDecoder struct {
+	decoder Decoder
+	source  io.Reader
+	reader  io.ReadCloser
+	err     error
+}
+
+// Read reads from the decoder, creating it first.
+func (d *lazyDecoder) Read(p []byte) (int, error) {
+	if d.reader == nil && d.err == nil {
+		// Decoders may return a typed nil reader with their error
+		if reader, err := d.decoder(d.source); err != nil {
+			d.err = err
+		} else {
+			d.reader = reader
+		}
+	}
+	if d.err != nil {
+		return 0, d.err
+	}
+	return d.reader.Read(p)
+}
+
+// Close closes the decoder if it was created.
+func (d *lazyDecoder) Close() error {
+	if d.reader == nil {
+		return nil
+	}
+	return d.reader.Close()
+}
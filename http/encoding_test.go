@@ -0,0 +1,320 @@
+package http
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"github.com/andybalholm/brotli"
+	"github.com/guhungry/gohungry/http/httptest"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// compress encodes 'data' with the writer created by 'newWriter'.
+func compress(t *testing.T, data string, newWriter func(w io.Writer) io.WriteCloser) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := newWriter(&buf)
+	if _, err := writer.Write([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// encoders used to build compressed test bodies
+var testEncoders = map[string]func(w io.Writer) io.WriteCloser{
+	"gzip":    func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+	"deflate": func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) },
+	"br":      func(w io.Writer) io.WriteCloser { return brotli.NewWriter(w) },
+	"zstd": func(w io.Writer) io.WriteCloser {
+		encoder, _ := zstd.NewWriter(w)
+		return encoder
+	},
+	"raw deflate": func(w io.Writer) io.WriteCloser {
+		writer, _ := flate.NewWriter(w, flate.DefaultCompression)
+		return writer
+	},
+}
+
+// compressedResponse builds a response with 'body' and the Content-Encoding header 'encoding'.
+func compressedResponse(body []byte, encoding string) *http.Response {
+	header := http.Header{}
+	if encoding != "" {
+		header.Set(HeaderContentEncoding, encoding)
+	}
+	return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(bytes.NewReader(body))}
+}
+
+func TestDecodeBody(t *testing.T) {
+	const message = `{"message":"success"}`
+	tests := []struct {
+		name     string
+		body     []byte
+		encoding string
+	}{
+		{name: "Identity", body: []byte(message), encoding: ""},
+		{name: "Gzip", body: compress(t, message, testEncoders["gzip"]), encoding: "gzip"},
+		{name: "Deflate", body: compress(t, message, testEncoders["deflate"]), encoding: "deflate"},
+		{name: "Raw Deflate", body: compress(t, message, testEncoders["raw deflate"]), encoding: "deflate"},
+		{name: "Brotli", body: compress(t, message, testEncoders["br"]), encoding: "br"},
+		{name: "Zstd", body: compress(t, message, testEncoders["zstd"]), encoding: "zstd"},
+		{name: "Case Insensitive", body: compress(t, message, testEncoders["gzip"]), encoding: "GZIP"},
+		{
+			name:     "Multiple Encodings",
+			body:     compress(t, string(compress(t, message, testEncoders["gzip"])), testEncoders["br"]),
+			encoding: "gzip, identity, br",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := decodeBody(compressedResponse(tt.body, tt.encoding))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			defer body.Close()
+			decoded, err := io.ReadAll(body)
+			if err != nil || string(decoded) != message {
+				t.Errorf("Expected %s, got %s, %v", message, decoded, err)
+			}
+		})
+	}
+}
+
+func TestDecodeBodyErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		response      *http.Response
+		expectedError error
+	}{
+		{name: "Unsupported Encoding", response: compressedResponse([]byte("data"), "compress"), expectedError: ErrUnsupportedContentEncoding},
+		{name: "Corrupt Body", response: compressedResponse([]byte("this is not a gzip stream"), "gzip"), expectedError: gzip.ErrHeader},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := decodeBody(tt.response)
+			if err == nil {
+				defer body.Close()
+				_, err = io.ReadAll(body)
+			}
+			if !errors.Is(err, tt.expectedError) {
+				t.Errorf("Expected %v, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestDecodeBodyEmpty(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		method   string
+		encoding string
+	}{
+		{name: "No Content Gzip", status: http.StatusNoContent, method: http.MethodGet, encoding: "gzip"},
+		{name: "Not Modified Deflate", status: http.StatusNotModified, method: http.MethodGet, encoding: "deflate"},
+		{name: "HEAD Unsupported Encoding", status: http.StatusOK, method: http.MethodHead, encoding: "compress"},
+		{name: "Empty OK Gzip", status: http.StatusOK, method: http.MethodGet, encoding: "gzip"},
+		{name: "Empty OK Deflate", status: http.StatusOK, method: http.MethodGet, encoding: "deflate"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetHTTPClient(&httptest.MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					res := compressedResponse(nil, tt.encoding)
+					res.StatusCode, res.Request = tt.status, req
+					return res, nil
+				},
+			})
+			defer ResetHTTPClient()
+
+			requestInfo := NewRequestInfo(tt.method, "https://example.com", nil, httptest.DummyRequestBodySerializer, readBody)
+			body, err := DoRequest(requestInfo)
+			if err != nil || *body != "" {
+				t.Errorf("Expected empty body, got %v, %v", body, err)
+			}
+		})
+	}
+}
+
+// readBody returns the response body as a string.
+func readBody(reader io.ReadCloser) (*string, error) {
+	body, err := io.ReadAll(reader)
+	result := string(body)
+	return &result, err
+}
+
+func TestRegisterDecoder(t *testing.T) {
+	RegisterDecoder("X-Reverse", func(r io.Reader) (io.ReadCloser, error) {
+		data, err := io.ReadAll(r)
+		for i, j := 0, len(data)-1; i < j; i, j = i+1, j-1 {
+			data[i], data[j] = data[j], data[i]
+		}
+		return io.NopCloser(bytes.NewReader(data)), err
+	})
+	defer func() {
+		decoders.mu.Lock()
+		delete(decoders.decoders, "x-reverse")
+		decoders.encodings = decoders.encodings[:len(decoders.encodings)-1]
+		decoders.mu.Unlock()
+	}()
+
+	if accept := decoders.acceptEncoding(); accept != "zstd, br, gzip, deflate, x-reverse" {
+		t.Errorf("Expected registered encoding to be advertised, got %s", accept)
+	}
+	body, err := decodeBody(compressedResponse([]byte("olleh"), "x-reverse"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if data, _ := io.ReadAll(body); string(data) != "hello" {
+		t.Errorf("Expected hello, got %s", data)
+	}
+}
+
+func TestDoRequestDecompressesResponse(t *testing.T) {
+	var acceptEncoding string
+	SetHTTPClient(&httptest.MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			acceptEncoding = req.Header.Get(HeaderAcceptEncoding)
+			return compressedResponse(compress(t, `{"message":"success"}`, testEncoders["zstd"]), "zstd"), nil
+		},
+	})
+	defer ResetHTTPClient()
+
+	tests := []struct {
+		name     string
+		options  []RequestInfoOption[map[string]string]
+		expected string
+	}{
+		{name: "Advertise Registered Encodings", expected: "zstd, br, gzip, deflate"},
+		{name: "Keep Caller Accept-Encoding", options: []RequestInfoOption[map[string]string]{WithHeader[map[string]string](HeaderAcceptEncoding, "zstd")}, expected: "zstd"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requestInfo := NewRequestInfo(MethodGet, "https://example.com", nil, httptest.DummyRequestBodySerializer, httptest.DummyResponseBodyParser[map[string]string], tt.options...)
+			response, err := DoRequest(requestInfo)
+			if err != nil || (*response)["message"] != "success" {
+				t.Fatalf("Expected decoded message, got %v, %v", response, err)
+			}
+			if acceptEncoding != tt.expected {
+				t.Errorf("Expected Accept-Encoding %s, got %s", tt.expected, acceptEncoding)
+			}
+		})
+	}
+}
+
+// readCompressed reads 'body' decoded with the decoder registered for 'encoding'.
+func readCompressed(t *testing.T, body io.Reader, encoding string) string {
+	t.Helper()
+	decoder, _ := decoders.decoder(encoding)
+	reader, err := decoder(body)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return string(data)
+}
+
+func TestCompressBody(t *testing.T) {
+	const data = "0123456789"
+	tests := []struct {
+		name       string
+		body       func() io.Reader
+		encoding   string
+		minSize    int
+		compressed bool
+	}{
+		{name: "No Compression", body: func() io.Reader { return bytes.NewReader([]byte(data)) }, encoding: "", minSize: 0, compressed: false},
+		{name: "Buffered Below Threshold", body: func() io.Reader { return bytes.NewReader([]byte(data)) }, encoding: "gzip", minSize: 11, compressed: false},
+		{name: "Buffered At Threshold", body: func() io.Reader { return bytes.NewReader([]byte(data)) }, encoding: "gzip", minSize: 10, compressed: true},
+		{name: "Streaming Below Threshold", body: func() io.Reader { return strings.NewReader(data) }, encoding: "zstd", minSize: 11, compressed: false},
+		{name: "Streaming At Threshold", body: func() io.Reader { return strings.NewReader(data) }, encoding: "zstd", minSize: 10, compressed: true},
+		{name: "Streaming Above Threshold", body: func() io.Reader { return strings.NewReader(data) }, encoding: "br", minSize: 4, compressed: true},
+		{name: "Streaming Zero Threshold", body: func() io.Reader { return strings.NewReader(data) }, encoding: "gzip", minSize: 0, compressed: true},
+		{name: "Streaming Negative Threshold", body: func() io.Reader { return strings.NewReader(data) }, encoding: "gzip", minSize: -1, compressed: true},
+		{name: "Buffered Negative Threshold", body: func() io.Reader { return bytes.NewReader([]byte(data)) }, encoding: "gzip", minSize: -1, compressed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, compressed, err := compressBody(tt.body(), tt.encoding, tt.minSize)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if compressed != tt.compressed {
+				t.Fatalf("Expected compressed %v, got %v", tt.compressed, compressed)
+			}
+
+			var result string
+			if compressed {
+				result = readCompressed(t, body, tt.encoding)
+			} else {
+				raw, _ := io.ReadAll(body)
+				result = string(raw)
+			}
+			if result != data {
+				t.Errorf("Expected body %s, got %s", data, result)
+			}
+		})
+	}
+}
+
+func TestCompressBodyUnsupportedEncoding(t *testing.T) {
+	_, _, err := compressBody(strings.NewReader("data"), "compress", 0)
+	if !errors.Is(err, ErrUnsupportedContentEncoding) {
+		t.Errorf("Expected ErrUnsupportedContentEncoding, got %v", err)
+	}
+}
+
+func TestDoRequestCompressesRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     any
+		encoding string
+		expected string
+	}{
+		{name: "Buffered Body", body: map[string]string{"key": "value"}, encoding: "gzip", expected: "dummy body"},
+		{name: "Streaming Body", body: StreamBody{Reader: strings.NewReader(strings.Repeat("streamed ", 100))}, encoding: "zstd", expected: strings.Repeat("streamed ", 100)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var contentEncoding, received string
+			SetHTTPClient(&httptest.MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					contentEncoding = req.Header.Get(HeaderContentEncoding)
+					received = readCompressed(t, req.Body, contentEncoding)
+					return compressedResponse([]byte(`{}`), ""), nil
+				},
+			})
+			defer ResetHTTPClient()
+
+			requestInfo := NewRequestInfo(MethodPost, "https://example.com", tt.body, httptest.DummyRequestBodySerializer, httptest.DummyResponseBodyParser[any],
+				WithRequestCompression[any](tt.encoding, 8))
+			if _, err := DoRequest(requestInfo); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if contentEncoding != tt.encoding {
+				t.Errorf("Expected Content-Encoding %s, got %s", tt.encoding, contentEncoding)
+			}
+			if received != tt.expected {
+				t.Errorf("Expected body %s, got %s", tt.expected, received)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+// Package graphql sends GraphQL queries over HTTP with the json package. It decodes the data
+// of the response into a generic type and returns the errors of the response as Errors,
+// alongside any partial data. It supports operation names and automatic persisted queries.
+package graphql
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	stdjson "encoding/json"
+	"errors"
+	"fmt"
+	"github.com/guhungry/gohungry/http"
+	"github.com/guhungry/gohungry/http/json"
+	"io"
+	"strings"
+)
+
+// ErrNoData is returned when a response has neither data nor errors.
+var ErrNoData = errors.New("gohungry: GraphQL response has no data")
+
+// Location is a position in the query document.
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Error is an entry of the errors list of a GraphQL response.
+type Error struct {
+	Message    string         `json:"message"`
+	Path       []any          `json:"path,omitempty"`      // Field names and list indexes of the failed field
+	Locations  []Location     `json:"locations,omitempty"` // Positions in the query document
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+// Error formats the message with the path of the failed field.
+func (e *Error) Error() string {
+	if len(e.Path) == 0 {
+		return e.Message
+	}
+	path := make([]string, len(e.Path))
+	for i, segment := range e.Path {
+		path[i] = fmt.Sprint(segment)
+	}
+	return e.Message + " (" + strings.Join(path, ".") + ")"
+}
+
+// Errors is the errors list of a GraphQL response. It may be returned together with partial data.
+type Errors []*Error
+
+// Error joins the messages of the errors.
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return "gohungry: GraphQL errors: " + strings.Join(messages, "; ")
+}
+
+// Unwrap returns the errors, so errors.As can find an *Error.
+func (e Errors) Unwrap() []error {
+	result := make([]error, len(e))
+	for i, err := range e {
+		result[i] = err
+	}
+	return result
+}
+
+// config holds the settings collected by Option.
+type config[T any] struct {
+	operationName  string
+	persisted      bool
+	requestOptions []http.RequestInfoOption[T]
+}
+
+// Option modifies a GraphQL request.
+type Option[T any] func(c *config[T])
+
+// WithOperationName selects the operation to execute when the query document has several.
+func WithOperationName[T any](name string) Option[T] {
+	return func(c *config[T]) {
+		c.operationName = name
+	}
+}
+
+// WithPersistedQuery sends the SHA-256 hash of the query instead of the query, as automatic persisted
+// queries. When the server does not know the hash yet, the query is sent again with its text.
+func WithPersistedQuery[T any]() Option[T] {
+	return func(c *config[T]) {
+		c.persisted = true
+	}
+}
+
+// WithRequestOptions customizes the HTTP request, e.g. with http.WithAuthBearer.
+func WithRequestOptions[T any](options ...http.RequestInfoOption[T]) Option[T] {
+	return func(c *config[T]) {
+		c.requestOptions = append(c.requestOptions, options...)
+	}
+}
+
+// request is the body of a GraphQL request.
+type request struct {
+	Query         string         `json:"query,omitempty"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+	Extensions    *extensions    `json:"extensions,omitempty"`
+}
+
+// extensions carries the hash of a persisted query.
+type extensions struct {
+	PersistedQuery persistedQuery `json:"persistedQuery"`
+}
+
+// persistedQuery is the automatic persisted query extension.
+type persistedQuery struct {
+	Version    int    `json:"version"`
+	SHA256Hash string `json:"sha256Hash"`
+}
+
+// response is the body of a GraphQL response.
+type response[T any] struct {
+	Data   *T     `json:"data"`
+	Errors Errors `json:"errors"`
+}
+
+// Query posts 'query' with 'variables' to 'endpoint' and decodes the data of the response into 'T'.
+// When the response has errors, it returns them as Errors together with any partial data.
+func Query[T any](ctx context.Context, endpoint string, query string, variables map[string]any, options ...Option[T]) (*T, error) {
+	c := &config[T]{}
+	for _, option := range options {
+		option(c)
+	}
+
+	body := request{Query: query, OperationName: c.operationName, Variables: variables}
+	if !c.persisted {
+		return post(ctx, endpoint, body, c)
+	}
+
+	hash := sha256.Sum256([]byte(query))
+	body.Query = ""
+	body.Extensions = &extensions{PersistedQuery: persistedQuery{Version: 1, SHA256Hash: hex.EncodeToString(hash[:])}}
+	data, err := post(ctx, endpoint, body, c)
+	var queryErrors Errors
+	if errors.As(err, &queryErrors) && isPersistedQueryNotFound(queryErrors) {
+		// Register the query with the server by sending its text along with the hash
+		body.Query = query
+		return post(ctx, endpoint, body, c)
+	}
+	return data, err
+}
+
+// post sends 'body' and decodes the response, keeping partial data when there are errors.
+func post[T any](ctx context.Context, endpoint string, body request, c *config[T]) (*T, error) {
+	var queryErrors Errors
+	parser := func(reader io.ReadCloser) (*T, error) {
+		var result response[T]
+		if err := stdjson.NewDecoder(reader).Decode(&result); err != nil {
+			return nil, err
+		}
+		if result.Data == nil && len(result.Errors) == 0 {
+			return nil, ErrNoData
+		}
+		queryErrors = result.Errors
+		return result.Data, nil
+	}
+
+	options := append([]http.RequestInfoOption[T]{http.WithContext[T](ctx)}, c.requestOptions...)
+	options = append(options, http.WithResponseParser(parser))
+	data, err := json.Post[T](endpoint, body, options...)
+	if err != nil {
+		return nil, err
+	}
+	if len(queryErrors) > 0 {
+		return data, queryErrors
+	}
+	return data, nil
+}
+
+// isPersistedQueryNotFound reports whether the server asks for the text of a persisted query.
+func isPersistedQueryNotFound(queryErrors Errors) bool {
+	for _, err := range queryErrors {
+		if err.Message == "PersistedQueryNotFound" || err.Extensions["code"] == "PERSISTED_QUERY_NOT_FOUND" {
+			return true
+		}
+	}
+	return false
+}
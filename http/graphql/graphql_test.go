@@ -0,0 +1,180 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/guhungry/gohungry/http/httptest"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	gohungry "github.com/guhungry/gohungry/http"
+)
+
+type user struct {
+	User struct {
+		Name string `json:"name"`
+	} `json:"user"`
+}
+
+// mockGraphQLClient records the decoded request bodies and replies with 'responses' in order.
+func mockGraphQLClient(t *testing.T, requests *[]map[string]any, responses ...string) *httptest.MockHTTPClient {
+	return &httptest.MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			var body map[string]any
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			*requests = append(*requests, body)
+			response := responses[len(*requests)-1]
+			return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(response))}, nil
+		},
+	}
+}
+
+func TestQuery(t *testing.T) {
+	var requests []map[string]any
+	gohungry.SetHTTPClient(mockGraphQLClient(t, &requests, `{"data":{"user":{"name":"Ann"}}}`))
+	defer gohungry.ResetHTTPClient()
+
+	result, err := Query[user](context.Background(), "http://example.com/graphql", "query GetUser($id: ID!) { user(id: $id) { name } }",
+		map[string]any{"id": "1"}, WithOperationName[user]("GetUser"))
+	if err != nil || result.User.Name != "Ann" {
+		t.Fatalf("Expected Ann, got %v, %v", result, err)
+	}
+
+	expected := map[string]any{
+		"query":         "query GetUser($id: ID!) { user(id: $id) { name } }",
+		"operationName": "GetUser",
+		"variables":     map[string]any{"id": "1"},
+	}
+	if !reflect.DeepEqual(requests[0], expected) {
+		t.Errorf("Expected request %v, got %v", expected, requests[0])
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		response     string
+		expectedData bool
+	}{
+		{
+			name:         "Partial Data",
+			response:     `{"data":{"user":{"name":"Ann"}},"errors":[{"message":"denied","path":["user","email"],"locations":[{"line":1,"column":9}],"extensions":{"code":"FORBIDDEN"}}]}`,
+			expectedData: true,
+		},
+		{
+			name:         "No Data",
+			response:     `{"data":null,"errors":[{"message":"denied","path":["user","email"],"locations":[{"line":1,"column":9}],"extensions":{"code":"FORBIDDEN"}}]}`,
+			expectedData: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []map[string]any
+			gohungry.SetHTTPClient(mockGraphQLClient(t, &requests, tt.response))
+			defer gohungry.ResetHTTPClient()
+
+			result, err := Query[user](context.Background(), "http://example.com/graphql", "{ user { name email } }", nil)
+			if (result != nil) != tt.expectedData {
+				t.Errorf("Expected data %v, got %v", tt.expectedData, result)
+			}
+
+			var queryErrors Errors
+			if !errors.As(err, &queryErrors) || len(queryErrors) != 1 {
+				t.Fatalf("Expected one GraphQL error, got %v", err)
+			}
+			var queryErr *Error
+			if !errors.As(err, &queryErr) {
+				t.Fatalf("Expected *Error, got %v", err)
+			}
+			expected := &Error{
+				Message:    "denied",
+				Path:       []any{"user", "email"},
+				Locations:  []Location{{Line: 1, Column: 9}},
+				Extensions: map[string]any{"code": "FORBIDDEN"},
+			}
+			if !reflect.DeepEqual(queryErr, expected) {
+				t.Errorf("Expected %+v, got %+v", expected, queryErr)
+			}
+			if queryErr.Error() != "denied (user.email)" {
+				t.Errorf("Expected message with path, got %s", queryErr.Error())
+			}
+		})
+	}
+}
+
+func TestQueryNoData(t *testing.T) {
+	var requests []map[string]any
+	gohungry.SetHTTPClient(mockGraphQLClient(t, &requests, `{}`))
+	defer gohungry.ResetHTTPClient()
+
+	if _, err := Query[user](context.Background(), "http://example.com/graphql", "{ user { name } }", nil); !errors.Is(err, ErrNoData) {
+		t.Errorf("Expected ErrNoData, got %v", err)
+	}
+}
+
+func TestQueryPersisted(t *testing.T) {
+	const query = "{ user { name } }"
+	const hash = "52978b222d1c8b138bf928ca07288c01cd4c553a1a8fe1322526b4ef6c6f6b48"
+	tests := []struct {
+		name      string
+		responses []string
+		withQuery []bool
+	}{
+		{name: "Known Hash", responses: []string{`{"data":{"user":{"name":"Ann"}}}`}, withQuery: []bool{false}},
+		{
+			name:      "Unknown Hash",
+			responses: []string{`{"errors":[{"message":"PersistedQueryNotFound"}]}`, `{"data":{"user":{"name":"Ann"}}}`},
+			withQuery: []bool{false, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []map[string]any
+			gohungry.SetHTTPClient(mockGraphQLClient(t, &requests, tt.responses...))
+			defer gohungry.ResetHTTPClient()
+
+			result, err := Query[user](context.Background(), "http://example.com/graphql", query, nil, WithPersistedQuery[user]())
+			if err != nil || result.User.Name != "Ann" {
+				t.Fatalf("Expected Ann, got %v, %v", result, err)
+			}
+			if len(requests) != len(tt.withQuery) {
+				t.Fatalf("Expected %d requests, got %d", len(tt.withQuery), len(requests))
+			}
+			for i, request := range requests {
+				extensions, _ := request["extensions"].(map[string]any)
+				persisted, _ := extensions["persistedQuery"].(map[string]any)
+				if persisted["sha256Hash"] != hash || persisted["version"] != float64(1) {
+					t.Errorf("Expected persisted query hash, got %v", request["extensions"])
+				}
+				if _, ok := request["query"]; ok != tt.withQuery[i] {
+					t.Errorf("Expected query text %v in request %d, got %v", tt.withQuery[i], i, request)
+				}
+			}
+		})
+	}
+}
+
+func TestQueryWithRequestOptions(t *testing.T) {
+	var authorization string
+	gohungry.SetHTTPClient(&httptest.MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			authorization = req.Header.Get(gohungry.HeaderAuthorization)
+			return httptest.MockHTTPClientSuccess(200, `{"data":{"user":{"name":"Ann"}}}`).Do(req)
+		},
+	})
+	defer gohungry.ResetHTTPClient()
+
+	_, err := Query[user](context.Background(), "http://example.com/graphql", "{ user { name } }", nil,
+		WithRequestOptions(gohungry.WithAuthBearer[user]("token")))
+	if err != nil || authorization != "Bearer token" {
+		t.Errorf("Expected bearer token, got %s, %v", authorization, err)
+	}
+}
@@ -0,0 +1,247 @@
+// Package hedge cuts tail latency by hedging GET and HEAD requests. When a request has not been
+// answered after a delay, such as the p95 latency of the upstream, an identical request is sent,
+// optionally to an alternate host. The first successful response wins and the other requests are
+// cancelled. Other methods are never hedged, even when idempotent: a PUT or a POST with an
+// Idempotency-Key sent twice concurrently would race with itself, typically ending in a
+// 409 Conflict. Stats reports how often hedges fire and win. Attach a Hedger with gohungry's
+// WithMiddleware client option.
+package hedge
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	gohungry "github.com/guhungry/gohungry/http"
+)
+
+// DefaultMaxHedges is the number of hedged requests sent in addition to the original one.
+const DefaultMaxHedges = 1
+
+// Stats counts the activity of a Hedger.
+type Stats struct {
+	Requests  int64 // GET and HEAD requests handled
+	Hedges    int64 // Hedged requests sent
+	HedgeWins int64 // Requests answered by a hedged request rather than the original one
+}
+
+// Hedger sends hedged requests.
+type Hedger struct {
+	delay      time.Duration
+	maxHedges  int
+	alternates []*url.URL
+	requests   atomic.Int64
+	hedges     atomic.Int64
+	hedgeWins  atomic.Int64
+}
+
+// Option modifies a Hedger.
+type Option func(h *Hedger)
+
+// WithMaxHedges sets how many hedged requests may be sent for one request, 'delay' apart.
+// Defaults to DefaultMaxHedges.
+func WithMaxHedges(n int) Option {
+	return func(h *Hedger) {
+		h.maxHedges = n
+	}
+}
+
+// WithAlternateHosts sends hedged requests to 'hosts' in turn instead of the original host.
+// A host is a base URL such as "https://replica.example.com:8443"; its scheme and host replace those of the request.
+func WithAlternateHosts(hosts ...*url.URL) Option {
+	return func(h *Hedger) {
+		h.alternates = append(h.alternates, hosts...)
+	}
+}
+
+// New creates a Hedger that sends a hedged request when no response arrived after 'delay'.
+func New(delay time.Duration, options ...Option) *Hedger {
+	result := &Hedger{delay: delay, maxHedges: DefaultMaxHedges}
+	for _, option := range options {
+		option(result)
+	}
+	return result
+}
+
+// Stats returns the counters of the Hedger.
+func (h *Hedger) Stats() Stats {
+	return Stats{Requests: h.requests.Load(), Hedges: h.hedges.Load(), HedgeWins: h.hedgeWins.Load()}
+}
+
+// Middleware hedges the GET and HEAD requests of a client. Other requests are sent once.
+func (h *Hedger) Middleware() gohungry.Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return gohungry.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if !isHedgeable(req) {
+				return next.RoundTrip(req)
+			}
+			h.requests.Add(1)
+			return h.roundTrip(next, req)
+		})
+	}
+}
+
+// attempt is the outcome of one of the requests sent for a request.
+type attempt struct {
+	index  int
+	res    *http.Response
+	err    error
+	cancel context.CancelFunc
+}
+
+// isHedgeable reports whether 'req' is a read that can safely be in flight more than once.
+func isHedgeable(req *http.Request) bool {
+	return (req.Method == "" || req.Method == http.MethodGet || req.Method == http.MethodHead) && gohungry.IsIdempotent(req)
+}
+
+// succeeded reports whether the attempt can answer the request. Server errors, conflicts and
+// throttling, which a hedge may cause by racing the original request, let the other attempts win.
+func (a *attempt) succeeded() bool {
+	if a.err != nil {
+		return false
+	}
+	switch a.res.StatusCode {
+	case http.StatusConflict, http.StatusTooManyRequests:
+		return false
+	}
+	return a.res.StatusCode < http.StatusInternalServerError
+}
+
+// discard releases the resources of an attempt that lost.
+func (a *attempt) discard() {
+	if a.res != nil {
+		a.res.Body.Close()
+	}
+	a.cancel()
+}
+
+// roundTrip sends 'req' and its hedges, returning the first successful response.
+// Cancelling 'req' cancels every attempt, so the loop ends once they return.
+func (h *Hedger) roundTrip(next http.RoundTripper, req *http.Request) (*http.Response, error) {
+	attempts := make(chan *attempt, h.maxHedges+1)
+	cancels := make([]context.CancelFunc, 0, h.maxHedges+1)
+	send := func() error {
+		index := len(cancels)
+		request, cancel, err := h.newAttempt(req, index)
+		if err != nil {
+			return err
+		}
+		cancels = append(cancels, cancel)
+		go func() {
+			res, err := next.RoundTrip(request)
+			attempts <- &attempt{index: index, res: res, err: err, cancel: cancel}
+		}()
+		return nil
+	}
+	if err := send(); err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(h.delay)
+	defer timer.Stop()
+	pending := 1
+	hedge := func() {
+		if len(cancels) > h.maxHedges || req.Context().Err() != nil {
+			return
+		}
+		if err := send(); err == nil {
+			h.hedges.Add(1)
+			pending++
+			if !timer.Stop() {
+				// Drop a tick that fired while this hedge was sent after a failure
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(h.delay)
+		}
+	}
+
+	var failed *attempt
+	for pending > 0 {
+		select {
+		case <-timer.C:
+			hedge()
+		case result := <-attempts:
+			pending--
+			if result.succeeded() {
+				h.win(result, cancels, attempts, pending)
+				return result.res, nil
+			}
+			// Keep the latest failure in case no attempt succeeds, and hedge without waiting
+			if failed != nil {
+				failed.discard()
+			}
+			failed = result
+			hedge()
+		}
+	}
+
+	if failed.res == nil {
+		failed.cancel()
+		return nil, failed.err
+	}
+	failed.res.Body = &cancelOnClose{ReadCloser: failed.res.Body, cancel: failed.cancel}
+	return failed.res, nil
+}
+
+// win cancels the attempts other than 'winner' and keeps the winner running until its body is closed.
+func (h *Hedger) win(winner *attempt, cancels []context.CancelFunc, attempts chan *attempt, pending int) {
+	if winner.index > 0 {
+		h.hedgeWins.Add(1)
+	}
+	for index, cancel := range cancels {
+		if index != winner.index {
+			cancel()
+		}
+	}
+	winner.res.Body = &cancelOnClose{ReadCloser: winner.res.Body, cancel: winner.cancel}
+
+	// Close the responses of the attempts still running once they return
+	go func() {
+		for range pending {
+			(<-attempts).discard()
+		}
+	}()
+}
+
+// newAttempt clones 'req' for attempt 'index' under its own context. Hedges go to the alternate hosts.
+func (h *Hedger) newAttempt(req *http.Request, index int) (*http.Request, context.CancelFunc, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	result := req.Clone(ctx)
+	if index == 0 {
+		return result, cancel, nil
+	}
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return nil, nil, err
+		}
+		result.Body = body
+	}
+	if len(h.alternates) > 0 {
+		alternate := h.alternates[(index-1)%len(h.alternates)]
+		result.URL.Scheme, result.URL.Host = alternate.Scheme, alternate.Host
+		result.Host = ""
+	}
+	return result, cancel, nil
+}
+
+// cancelOnClose cancels the context of a winning attempt when its body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close closes the body and cancels the attempt.
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
@@ -0,0 +1,137 @@
+package hedge
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	nethttptest "net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gohungry "github.com/guhungry/gohungry/http"
+)
+
+// newServer starts a server answering 'name' after 'delay', unless the request is cancelled first.
+func newServer(t *testing.T, name string, status int, delay time.Duration, requests *atomic.Int32) *nethttptest.Server {
+	server := nethttptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		select {
+		case <-time.After(delay):
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(name))
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// get sends a request through 'hedger' and returns the body of the response.
+func get(t *testing.T, hedger *Hedger, method string, target string) (string, error) {
+	t.Helper()
+	client, _ := gohungry.NewClient(gohungry.WithMiddleware(hedger.Middleware()))
+	req, _ := http.NewRequest(method, target, nil)
+	res, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	return string(body), err
+}
+
+func TestHedger(t *testing.T) {
+	tests := []struct {
+		name            string
+		primaryDelay    time.Duration
+		primaryStatus   int
+		alternateStatus int
+		method          string
+		expected        string
+		expectedStats   Stats
+	}{
+		{name: "Fast Primary", primaryDelay: 0, primaryStatus: http.StatusOK, alternateStatus: http.StatusOK, method: http.MethodGet, expected: "primary", expectedStats: Stats{Requests: 1}},
+		{name: "Slow Primary", primaryDelay: time.Second, primaryStatus: http.StatusOK, alternateStatus: http.StatusOK, method: http.MethodGet, expected: "alternate", expectedStats: Stats{Requests: 1, Hedges: 1, HedgeWins: 1}},
+		{name: "Failing Primary", primaryDelay: 0, primaryStatus: http.StatusServiceUnavailable, alternateStatus: http.StatusOK, method: http.MethodGet, expected: "alternate", expectedStats: Stats{Requests: 1, Hedges: 1, HedgeWins: 1}},
+		{name: "Conflicting Hedge", primaryDelay: 100 * time.Millisecond, primaryStatus: http.StatusOK, alternateStatus: http.StatusConflict, method: http.MethodGet, expected: "primary", expectedStats: Stats{Requests: 1, Hedges: 1}},
+		{name: "Throttled Hedge", primaryDelay: 100 * time.Millisecond, primaryStatus: http.StatusOK, alternateStatus: http.StatusTooManyRequests, method: http.MethodGet, expected: "primary", expectedStats: Stats{Requests: 1, Hedges: 1}},
+		{name: "Not Idempotent", primaryDelay: 100 * time.Millisecond, primaryStatus: http.StatusOK, alternateStatus: http.StatusOK, method: http.MethodPost, expected: "primary", expectedStats: Stats{}},
+		{name: "Idempotent Write", primaryDelay: 100 * time.Millisecond, primaryStatus: http.StatusOK, alternateStatus: http.StatusOK, method: http.MethodPut, expected: "primary", expectedStats: Stats{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var primaryRequests, alternateRequests atomic.Int32
+			primary := newServer(t, "primary", tt.primaryStatus, tt.primaryDelay, &primaryRequests)
+			alternate := newServer(t, "alternate", tt.alternateStatus, 0, &alternateRequests)
+			alternateURL, _ := url.Parse(alternate.URL)
+
+			hedger := New(20*time.Millisecond, WithAlternateHosts(alternateURL))
+			body, err := get(t, hedger, tt.method, primary.URL)
+			if err != nil || body != tt.expected {
+				t.Fatalf("Expected %s, got %s, %v", tt.expected, body, err)
+			}
+			if stats := hedger.Stats(); stats != tt.expectedStats {
+				t.Errorf("Expected %+v, got %+v", tt.expectedStats, stats)
+			}
+			if int64(alternateRequests.Load()) != tt.expectedStats.Hedges {
+				t.Errorf("Expected %d requests to the alternate host, got %d", tt.expectedStats.Hedges, alternateRequests.Load())
+			}
+		})
+	}
+}
+
+func TestHedgerIdempotencyKey(t *testing.T) {
+	var requests atomic.Int32
+	server := newServer(t, "created", http.StatusCreated, 100*time.Millisecond, &requests)
+
+	hedger := New(10 * time.Millisecond)
+	client, _ := gohungry.NewClient(gohungry.WithMiddleware(hedger.Middleware()))
+	req, _ := http.NewRequest(http.MethodPost, server.URL, nil)
+	req.Header.Set(gohungry.HeaderIdempotencyKey, "order-1")
+	res, err := client.Do(req)
+	if err != nil || res.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected 201, got %v, %v", res, err)
+	}
+	res.Body.Close()
+
+	if requests.Load() != 1 || hedger.Stats() != (Stats{}) {
+		t.Errorf("Expected a POST with an Idempotency-Key to be sent once, got %d requests and %+v", requests.Load(), hedger.Stats())
+	}
+}
+
+func TestHedgerAllFail(t *testing.T) {
+	var requests atomic.Int32
+	server := newServer(t, "down", http.StatusBadGateway, 0, &requests)
+
+	hedger := New(time.Second, WithMaxHedges(2))
+	body, err := get(t, hedger, http.MethodGet, server.URL)
+	if err != nil || body != "down" {
+		t.Fatalf("Expected the last failed response, got %s, %v", body, err)
+	}
+	if requests.Load() != 3 || hedger.Stats().Hedges != 2 {
+		t.Errorf("Expected the original request and 2 hedges, got %d requests and %+v", requests.Load(), hedger.Stats())
+	}
+}
+
+func TestHedgerCancelled(t *testing.T) {
+	var requests atomic.Int32
+	server := newServer(t, "slow", http.StatusOK, time.Second, &requests)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	client, _ := gohungry.NewClient(gohungry.WithMiddleware(New(10 * time.Millisecond).Middleware()))
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+
+	start := time.Now()
+	_, err := client.Do(req)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected the attempts to stop with the request, took %v", elapsed)
+	}
+}
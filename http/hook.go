@@ -0,0 +1,143 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Stage is a step of DoRequest reported to hooks.
+type Stage string
+
+// Stages of DoRequest
+const (
+	StageSerialize Stage = "serialize" // Serializing the request body
+	StageDecode    Stage = "decode"    // Parsing the response body
+)
+
+// RequestEvent describes a request sent by DoRequest. The response fields are set before RequestEnd.
+type RequestEvent struct {
+	Method       string        // HTTP method
+	URL          string        // Request URL without user info and with credentials redacted
+	Route        string        // Route template set by WithRoute, e.g. "/users/{id}"
+	Start        time.Time     // Time DoRequest started
+	RequestSize  int64         // Size of the serialized request body, -1 when streamed
+	StatusCode   int           // HTTP status code, 0 when no response was received
+	ResponseSize int64         // Bytes of the response body read, as received
+	Duration     time.Duration // Time DoRequest took
+	Err          error         // Error returned by DoRequest
+}
+
+// MethodOther stands for every non-standard method in telemetry, as in the OpenTelemetry semantic conventions.
+const MethodOther = "_OTHER"
+
+// TelemetryMethod returns 'method' as reported by hooks: GET when empty, and MethodOther for
+// non-standard methods, so they share one metric series or span name.
+func TelemetryMethod(method string) string {
+	switch method {
+	case "":
+		return http.MethodGet
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return MethodOther
+}
+
+// Hook observes the requests sent by DoRequest, e.g. for tracing or metrics.
+// Its methods are called synchronously, so they should return quickly.
+type Hook interface {
+	// RequestStart is called when DoRequest starts. The returned context is used for the request,
+	// e.g. to carry a span.
+	RequestStart(ctx context.Context, event *RequestEvent) context.Context
+	// RequestSend is called before the request is sent, e.g. to add propagation headers.
+	RequestSend(ctx context.Context, req *http.Request)
+	// StageEnd is called when a stage that began at 'start' completes.
+	StageEnd(ctx context.Context, stage Stage, start time.Time, err error)
+	// RequestEnd is called when DoRequest returns.
+	RequestEnd(ctx context.Context, event *RequestEvent)
+}
+
+// clientHooks holds the client-wide hooks applied by NewRequestInfo.
+var clientHooks struct {
+	mu    sync.RWMutex
+	hooks []Hook
+}
+
+// SetHooks replaces the client-wide hooks observing requests created afterwards. Call it without
+// arguments to remove them. WithHook adds hooks to a single request.
+func SetHooks(h ...Hook) {
+	clientHooks.mu.Lock()
+	defer clientHooks.mu.Unlock()
+	clientHooks.hooks = h
+}
+
+// defaultHooks returns a copy of the client-wide hooks.
+func defaultHooks() []Hook {
+	clientHooks.mu.RLock()
+	defer clientHooks.mu.RUnlock()
+	return append([]Hook(nil), clientHooks.hooks...)
+}
+
+// WithHook makes 'hook' observe the request, in addition to the client-wide hooks.
+func WithHook[Response any](hook Hook) RequestInfoOption[Response] {
+	return func(c *RequestInfo[Response]) {
+		c.hooks = append(c.hooks, hook)
+	}
+}
+
+// eventURL returns 'rawURL' without user info and with the credentials of 'auth' redacted.
+func eventURL(rawURL string, auth AuthCredentials) string {
+	if parsed, err := url.Parse(rawURL); err == nil && parsed.User != nil {
+		parsed.User = nil
+		rawURL = parsed.String()
+	}
+	return auth.redact(rawURL)
+}
+
+// WithRoute sets the route template of the request, e.g. "/users/{id}". Hooks report it in place
+// of the URL path to keep the cardinality of metric labels bounded.
+func WithRoute[Response any](template string) RequestInfoOption[Response] {
+	return func(c *RequestInfo[Response]) {
+		c.route = template
+	}
+}
+
+// hookRun calls the hooks of one request, each with the context it returned from RequestStart.
+type hookRun struct {
+	hooks    []Hook
+	contexts []context.Context
+}
+
+// startHooks calls RequestStart of 'hooks' and returns the context for the request.
+func startHooks(ctx context.Context, h []Hook, event *RequestEvent) (context.Context, *hookRun) {
+	run := &hookRun{hooks: h, contexts: make([]context.Context, len(h))}
+	for i, hook := range h {
+		ctx = hook.RequestStart(ctx, event)
+		run.contexts[i] = ctx
+	}
+	return ctx, run
+}
+
+// send calls RequestSend of the hooks.
+func (r *hookRun) send(req *http.Request) {
+	for i, hook := range r.hooks {
+		hook.RequestSend(r.contexts[i], req)
+	}
+}
+
+// stageEnd calls StageEnd of the hooks.
+func (r *hookRun) stageEnd(stage Stage, start time.Time, err error) {
+	for i, hook := range r.hooks {
+		hook.StageEnd(r.contexts[i], stage, start, err)
+	}
+}
+
+// end calls RequestEnd of the hooks, the last started first.
+func (r *hookRun) end(event *RequestEvent) {
+	for i := len(r.hooks) - 1; i >= 0; i-- {
+		r.hooks[i].RequestEnd(r.contexts[i], event)
+	}
+}
@@ -0,0 +1,105 @@
+package json
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/guhungry/gohungry/http"
+	"io"
+)
+
+// ErrTrailingData is returned by DisallowTrailingData when data follows the JSON value.
+var ErrTrailingData = errors.New("gohungry: trailing data after JSON value")
+
+// MarshalFunc serializes a value into JSON, e.g. the Marshal function of a faster JSON library.
+type MarshalFunc func(v any) ([]byte, error)
+
+// UnmarshalFunc decodes JSON into 'v', e.g. the Unmarshal function of a faster JSON library.
+type UnmarshalFunc func(data []byte, v any) error
+
+// decodeConfig holds the settings collected by DecodeOption.
+type decodeConfig struct {
+	disallowUnknownFields bool
+	useNumber             bool
+	disallowTrailingData  bool
+	unmarshal             UnmarshalFunc
+}
+
+// DecodeOption modifies how a JSON response is decoded.
+type DecodeOption func(c *decodeConfig)
+
+// DisallowUnknownFields fails decoding when an object has a key that matches no field of the destination.
+func DisallowUnknownFields() DecodeOption {
+	return func(c *decodeConfig) {
+		c.disallowUnknownFields = true
+	}
+}
+
+// UseNumber decodes numbers into interface values as json.Number instead of float64.
+func UseNumber() DecodeOption {
+	return func(c *decodeConfig) {
+		c.useNumber = true
+	}
+}
+
+// DisallowTrailingData fails decoding with ErrTrailingData when anything but white space follows the JSON value.
+func DisallowTrailingData() DecodeOption {
+	return func(c *decodeConfig) {
+		c.disallowTrailingData = true
+	}
+}
+
+// WithUnmarshalFunc decodes the whole body with 'unmarshal' instead of encoding/json.
+// The other decode options only apply to encoding/json, so configure the library itself instead.
+func WithUnmarshalFunc(unmarshal UnmarshalFunc) DecodeOption {
+	return func(c *decodeConfig) {
+		c.unmarshal = unmarshal
+	}
+}
+
+// WithDecodeOptions decodes the JSON response according to 'options'.
+func WithDecodeOptions[Response any](options ...DecodeOption) http.RequestInfoOption[Response] {
+	config := &decodeConfig{}
+	for _, option := range options {
+		option(config)
+	}
+	return http.WithResponseParser(func(reader io.ReadCloser) (*Response, error) {
+		return decodeResponse[Response](reader, config)
+	})
+}
+
+// WithMarshalFunc serializes the request body with 'marshal' instead of encoding/json.
+func WithMarshalFunc[Response any](marshal MarshalFunc) http.RequestInfoOption[Response] {
+	return http.WithBodySerializer[Response](http.RequestBodySerializer(marshal))
+}
+
+// decodeResponse decodes JSON into 'Response' according to 'config'.
+func decodeResponse[Response any](reader io.Reader, config *decodeConfig) (*Response, error) {
+	var result Response
+	if config.unmarshal != nil {
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+		if err := config.unmarshal(data, &result); err != nil {
+			return nil, err
+		}
+		return &result, nil
+	}
+
+	decoder := json.NewDecoder(reader)
+	if config.disallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+	if config.useNumber {
+		decoder.UseNumber()
+	}
+	if err := decoder.Decode(&result); err != nil {
+		return nil, err
+	}
+	if config.disallowTrailingData {
+		if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+			return nil, ErrTrailingData
+		}
+	}
+	return &result, nil
+}
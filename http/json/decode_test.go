@@ -0,0 +1,95 @@
+package json
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/guhungry/gohungry/http/httptest"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	gohungry "github.com/guhungry/gohungry/http"
+)
+
+func TestDecodeResponse(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		options       []DecodeOption
+		expectedError bool
+	}{
+		{name: "Unknown Fields Allowed", body: `{"message":"ok","extra":1}`, expectedError: false},
+		{name: "Unknown Fields Disallowed", body: `{"message":"ok","extra":1}`, options: []DecodeOption{DisallowUnknownFields()}, expectedError: true},
+		{name: "Trailing Data Ignored", body: `{"message":"ok"} garbage`, expectedError: false},
+		{name: "Trailing Data Disallowed", body: `{"message":"ok"} garbage`, options: []DecodeOption{DisallowTrailingData()}, expectedError: true},
+		{name: "Second Value Disallowed", body: `{"message":"ok"}{"message":"again"}`, options: []DecodeOption{DisallowTrailingData()}, expectedError: true},
+		{name: "Trailing White Space Allowed", body: "{\"message\":\"ok\"}\n\t ", options: []DecodeOption{DisallowTrailingData()}, expectedError: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &decodeConfig{}
+			for _, option := range tt.options {
+				option(config)
+			}
+			result, err := decodeResponse[MockResponse](strings.NewReader(tt.body), config)
+			if (err != nil) != tt.expectedError {
+				t.Fatalf("Expected error %v, got %v", tt.expectedError, err)
+			}
+			if err == nil && result.Message != "ok" {
+				t.Errorf("Expected message ok, got %s", result.Message)
+			}
+		})
+	}
+}
+
+func TestDecodeResponseTrailingDataError(t *testing.T) {
+	config := &decodeConfig{disallowTrailingData: true}
+	if _, err := decodeResponse[MockResponse](strings.NewReader(`{} x`), config); !errors.Is(err, ErrTrailingData) {
+		t.Errorf("Expected ErrTrailingData, got %v", err)
+	}
+}
+
+func TestGetWithUseNumber(t *testing.T) {
+	gohungry.SetHTTPClient(httptest.MockHTTPClientSuccess(200, `{"id":12345678901234567890}`))
+	defer gohungry.ResetHTTPClient()
+
+	response, err := Get[map[string]any]("http://example.com", WithDecodeOptions[map[string]any](UseNumber()))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if number, ok := (*response)["id"].(json.Number); !ok || number.String() != "12345678901234567890" {
+		t.Errorf("Expected json.Number, got %T %v", (*response)["id"], (*response)["id"])
+	}
+}
+
+func TestPostWithCustomMarshalAndUnmarshal(t *testing.T) {
+	var sent string
+	gohungry.SetHTTPClient(&httptest.MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			body, _ := io.ReadAll(req.Body)
+			sent = string(body)
+			return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(`{"message":"created"}`))}, nil
+		},
+	})
+	defer gohungry.ResetHTTPClient()
+
+	var unmarshalled bool
+	response, err := Post[MockResponse]("http://example.com", map[string]string{"key": "value"},
+		WithMarshalFunc[MockResponse](func(v any) ([]byte, error) { return []byte(`{"custom":true}`), nil }),
+		WithDecodeOptions[MockResponse](WithUnmarshalFunc(func(data []byte, v any) error {
+			unmarshalled = true
+			return json.Unmarshal(data, v)
+		})),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if sent != `{"custom":true}` {
+		t.Errorf("Expected custom marshal output, got %s", sent)
+	}
+	if !unmarshalled || response.Message != "created" {
+		t.Errorf("Expected custom unmarshal to decode created, got %v, %s", unmarshalled, response.Message)
+	}
+}
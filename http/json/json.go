@@ -19,14 +19,14 @@ func Get[Response any](url string, options ...http.RequestInfoOption[Response])
 }
 
 // Post performs an HTTP POST with JSON body, decodes response into 'Response'.
-// 'url' is the request target, 'body' is the payload, 'options' customize the request.
+// 'url' is the request target, 'body' is the payload or an http.StreamBody, 'options' customize the request.
 func Post[Response any](url string, body any, options ...http.RequestInfoOption[Response]) (*Response, error) {
 	return requestJSON[Response](http.MethodPost, url, body, options...)
 }
 
 // requestJSON sends an HTTP request and decodes JSON response into 'Response'.
 // 'method' is the HTTP method, 'url' is the request target, 'body' is the payload for POST,
-// 'options' customize the request.
+// 'options' customize the request, e.g. WithDecodeOptions or WithMarshalFunc.
 func requestJSON[Response any](method string, url string, body any, options ...http.RequestInfoOption[Response]) (*Response, error) {
 	options = append(options,
 		http.WithAccept[Response](contentType),
@@ -37,11 +37,7 @@ func requestJSON[Response any](method string, url string, body any, options ...h
 }
 
 // toResponseObject decodes JSON into 'Response'.
+// Use WithDecodeOptions for stricter decoding.
 func toResponseObject[Response any](reader io.ReadCloser) (*Response, error) {
-	var result Response
-	decoder := json.NewDecoder(reader)
-	if err := decoder.Decode(&result); err != nil {
-		return nil, err
-	}
-	return &result, nil
+	return decodeResponse[Response](reader, &decodeConfig{})
 }
@@ -1,6 +1,7 @@
 package json
 
 import (
+	"errors"
 	"github.com/guhungry/gohungry/http/httptest"
 	"testing"
 
@@ -53,3 +54,14 @@ func TestGetWithError(t *testing.T) {
 		t.Fatalf("Expected network error, got %v", err)
 	}
 }
+
+func TestGetWithMaxResponseBytes(t *testing.T) {
+	mockClient := httptest.MockHTTPClientSuccess(200, `{"message":"success"}`)
+	gohungry.SetHTTPClient(mockClient)
+	defer gohungry.ResetHTTPClient()
+
+	_, err := Get[MockResponse]("http://example.com", gohungry.WithMaxResponseBytes[MockResponse](10))
+	if !errors.Is(err, gohungry.ErrResponseTooLarge) {
+		t.Fatalf("Expected ErrResponseTooLarge, got %v", err)
+	}
+}
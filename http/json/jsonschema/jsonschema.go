@@ -0,0 +1,538 @@
+// Package jsonschema validates JSON documents against a subset of JSON Schema draft 2020-12.
+// Supported keywords are type, enum, const, required, properties, additionalProperties, items,
+// pattern, minLength, maxLength, minimum, maximum, exclusiveMinimum, exclusiveMaximum, minItems,
+// maxItems, allOf, anyOf, oneOf and local $ref into $defs or any other part of the schema.
+// Validation reports every violation with the JSON Pointer of the offending value.
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrInvalidSchema is returned by Compile when the schema itself is not usable.
+var ErrInvalidSchema = errors.New("gohungry: invalid JSON schema")
+
+// Violation describes a value that does not satisfy a schema keyword.
+type Violation struct {
+	Path    string // JSON Pointer of the value in the document, empty for the root
+	Keyword string // Schema keyword that failed, e.g. "required"
+	Message string // Description of the failure
+}
+
+// String formats the violation as "path: message".
+func (v Violation) String() string {
+	path := v.Path
+	if path == "" {
+		path = "/"
+	}
+	return path + ": " + v.Message
+}
+
+// ValidationError lists every violation found in a document.
+type ValidationError struct {
+	Violations []Violation
+}
+
+// Error lists the violations.
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		messages[i] = violation.String()
+	}
+	return "gohungry: JSON schema validation failed: " + strings.Join(messages, "; ")
+}
+
+// Schema is a compiled JSON Schema.
+type Schema struct {
+	root     any
+	patterns map[string]*regexp.Regexp
+}
+
+// Compile parses the JSON Schema in 'data'.
+func Compile(data []byte) (*Schema, error) {
+	root, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSchema, err)
+	}
+
+	result := &Schema{root: root, patterns: make(map[string]*regexp.Regexp)}
+	if err := result.check(root, make(map[uintptr]bool), make(map[uintptr]bool)); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSchema, err)
+	}
+	return result, nil
+}
+
+// MustCompile is like Compile but panics when the schema is invalid.
+// It simplifies initializing package level schemas.
+func MustCompile(data []byte) *Schema {
+	result, err := Compile(data)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// Validate checks the JSON document in 'data'. It returns a *ValidationError listing
+// every violation, or the syntax error when 'data' is not JSON.
+func (s *Schema) Validate(data []byte) error {
+	value, err := decode(data)
+	if err != nil {
+		return err
+	}
+
+	var violations []Violation
+	s.validate(s.root, value, "", &violations)
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+	return nil
+}
+
+// decode parses a single JSON value, keeping numbers exact.
+func decode(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var result any
+	if err := decoder.Decode(&result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// check compiles the patterns of 'schema' and verifies its references. 'visited' holds the
+// objects already checked, so recursive schemas are checked once, and 'loopFree' those known
+// not to loop back to themselves.
+func (s *Schema) check(schema any, visited, loopFree map[uintptr]bool) error {
+	node, ok := schema.(map[string]any)
+	if !ok {
+		if _, ok := schema.(bool); !ok {
+			return fmt.Errorf("schema must be an object or a boolean, got %T", schema)
+		}
+		return nil
+	}
+	id := reflect.ValueOf(node).Pointer()
+	if visited[id] {
+		return nil
+	}
+	visited[id] = true
+
+	if pattern, ok := node["pattern"].(string); ok {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return err
+		}
+		s.patterns[pattern] = compiled
+	}
+	if ref, ok := node["$ref"].(string); ok {
+		target, err := s.resolve(ref)
+		if err != nil {
+			return err
+		}
+		if err := s.check(target, visited, loopFree); err != nil {
+			return err
+		}
+	}
+	if err := s.checkLoops(node, make(map[uintptr]bool), loopFree); err != nil {
+		return err
+	}
+
+	for keyword, value := range node {
+		switch keyword {
+		case "properties", "$defs", "definitions":
+			children, _ := value.(map[string]any)
+			for _, child := range children {
+				if err := s.check(child, visited, loopFree); err != nil {
+					return err
+				}
+			}
+		case "additionalProperties", "items", "not":
+			if err := s.check(value, visited, loopFree); err != nil {
+				return err
+			}
+		case "allOf", "anyOf", "oneOf":
+			children, _ := value.([]any)
+			for _, child := range children {
+				if err := s.check(child, visited, loopFree); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkLoops rejects a $ref that leads back to 'schema' through $ref, allOf, anyOf, oneOf or not
+// only. Such a schema applies to the same value again without moving to a property or item, so
+// validating any value against it never ends. 'path' holds the schemas being followed.
+func (s *Schema) checkLoops(schema any, path, loopFree map[uintptr]bool) error {
+	node, ok := schema.(map[string]any)
+	if !ok {
+		return nil
+	}
+	id := reflect.ValueOf(node).Pointer()
+	if loopFree[id] {
+		return nil
+	}
+	path[id] = true
+
+	var children []any
+	for _, keyword := range []string{"allOf", "anyOf", "oneOf"} {
+		list, _ := node[keyword].([]any)
+		children = append(children, list...)
+	}
+	if not, ok := node["not"]; ok {
+		children = append(children, not)
+	}
+	if ref, ok := node["$ref"].(string); ok {
+		// References were resolved by check
+		target, _ := s.resolve(ref)
+		if target, ok := target.(map[string]any); ok && path[reflect.ValueOf(target).Pointer()] {
+			return fmt.Errorf("$ref %s refers to itself", ref)
+		}
+		children = append(children, target)
+	}
+	for _, child := range children {
+		if err := s.checkLoops(child, path, loopFree); err != nil {
+			return err
+		}
+	}
+
+	delete(path, id)
+	loopFree[id] = true
+	return nil
+}
+
+// resolve returns the part of the schema a local $ref such as "#/$defs/user" points at.
+func (s *Schema) resolve(ref string) (any, error) {
+	pointer, ok := strings.CutPrefix(ref, "#")
+	if !ok {
+		return nil, fmt.Errorf("only local $ref is supported, got %s", ref)
+	}
+
+	current := s.root
+	if pointer == "" {
+		return current, nil
+	}
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch node := current.(type) {
+		case map[string]any:
+			child, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("$ref %s not found", ref)
+			}
+			current = child
+		case []any:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, fmt.Errorf("$ref %s not found", ref)
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("$ref %s not found", ref)
+		}
+	}
+	return current, nil
+}
+
+// validate appends the violations of 'value' at 'path' against 'schema' to 'violations'.
+func (s *Schema) validate(schema any, value any, path string, violations *[]Violation) {
+	fail := func(keyword, format string, args ...any) {
+		*violations = append(*violations, Violation{Path: path, Keyword: keyword, Message: fmt.Sprintf(format, args...)})
+	}
+
+	node, ok := schema.(map[string]any)
+	if !ok {
+		if schema == false {
+			fail("false", "no value is allowed")
+		}
+		return
+	}
+
+	if ref, ok := node["$ref"].(string); ok {
+		// References were verified by Compile
+		target, _ := s.resolve(ref)
+		s.validate(target, value, path, violations)
+	}
+	if types, ok := node["type"]; ok && !matchesType(types, value) {
+		fail("type", "expected type %s, got %s", formatTypes(types), typeOf(value))
+		// The remaining keywords describe a value of another type
+		return
+	}
+	if enum, ok := node["enum"].([]any); ok && !containsJSON(enum, value) {
+		fail("enum", "value must be one of %s", formatJSON(enum))
+	}
+	if constant, ok := node["const"]; ok && !equalJSON(constant, value) {
+		fail("const", "value must be %s", formatJSON(constant))
+	}
+
+	s.validateCombinators(node, value, path, violations, fail)
+
+	switch typed := value.(type) {
+	case map[string]any:
+		s.validateObject(node, typed, path, violations, fail)
+	case []any:
+		s.validateArray(node, typed, path, violations, fail)
+	case string:
+		validateString(node, typed, s.patterns, fail)
+	case json.Number:
+		validateNumber(node, typed, fail)
+	}
+}
+
+// validateCombinators applies allOf, anyOf, oneOf and not.
+func (s *Schema) validateCombinators(node map[string]any, value any, path string, violations *[]Violation, fail func(keyword, format string, args ...any)) {
+	if schemas, ok := node["allOf"].([]any); ok {
+		for _, schema := range schemas {
+			s.validate(schema, value, path, violations)
+		}
+	}
+	if schemas, ok := node["anyOf"].([]any); ok && s.countValid(schemas, value, path) == 0 {
+		fail("anyOf", "value must match at least one schema of anyOf")
+	}
+	if schemas, ok := node["oneOf"].([]any); ok {
+		if count := s.countValid(schemas, value, path); count != 1 {
+			fail("oneOf", "value must match exactly one schema of oneOf, matched %d", count)
+		}
+	}
+	if schema, ok := node["not"]; ok && s.countValid([]any{schema}, value, path) == 1 {
+		fail("not", "value must not match the schema of not")
+	}
+}
+
+// countValid returns how many of 'schemas' accept 'value'.
+func (s *Schema) countValid(schemas []any, value any, path string) int {
+	result := 0
+	for _, schema := range schemas {
+		var violations []Violation
+		s.validate(schema, value, path, &violations)
+		if len(violations) == 0 {
+			result++
+		}
+	}
+	return result
+}
+
+// validateObject applies the object keywords.
+func (s *Schema) validateObject(node map[string]any, value map[string]any, path string, violations *[]Violation, fail func(keyword, format string, args ...any)) {
+	if required, ok := node["required"].([]any); ok {
+		for _, name := range required {
+			if name, ok := name.(string); ok {
+				if _, ok := value[name]; !ok {
+					fail("required", "missing required property %q", name)
+				}
+			}
+		}
+	}
+
+	properties, _ := node["properties"].(map[string]any)
+	additional, hasAdditional := node["additionalProperties"]
+	for _, name := range sortedKeys(value) {
+		childPath := path + "/" + escapePointer(name)
+		if schema, ok := properties[name]; ok {
+			s.validate(schema, value[name], childPath, violations)
+		} else if hasAdditional {
+			if additional == false {
+				*violations = append(*violations, Violation{Path: childPath, Keyword: "additionalProperties", Message: "property is not allowed"})
+				continue
+			}
+			s.validate(additional, value[name], childPath, violations)
+		}
+	}
+}
+
+// validateArray applies the array keywords.
+func (s *Schema) validateArray(node map[string]any, value []any, path string, violations *[]Violation, fail func(keyword, format string, args ...any)) {
+	if minItems, ok := integer(node["minItems"]); ok && len(value) < minItems {
+		fail("minItems", "expected at least %d items, got %d", minItems, len(value))
+	}
+	if maxItems, ok := integer(node["maxItems"]); ok && len(value) > maxItems {
+		fail("maxItems", "expected at most %d items, got %d", maxItems, len(value))
+	}
+	if items, ok := node["items"]; ok {
+		for i, item := range value {
+			s.validate(items, item, path+"/"+strconv.Itoa(i), violations)
+		}
+	}
+}
+
+// validateString applies the string keywords.
+func validateString(node map[string]any, value string, patterns map[string]*regexp.Regexp, fail func(keyword, format string, args ...any)) {
+	length := utf8.RuneCountInString(value)
+	if minLength, ok := integer(node["minLength"]); ok && length < minLength {
+		fail("minLength", "expected at least %d characters, got %d", minLength, length)
+	}
+	if maxLength, ok := integer(node["maxLength"]); ok && length > maxLength {
+		fail("maxLength", "expected at most %d characters, got %d", maxLength, length)
+	}
+	if pattern, ok := node["pattern"].(string); ok && !patterns[pattern].MatchString(value) {
+		fail("pattern", "value does not match pattern %q", pattern)
+	}
+}
+
+// validateNumber applies the numeric keywords.
+func validateNumber(node map[string]any, value json.Number, fail func(keyword, format string, args ...any)) {
+	number, _ := value.Float64()
+	if minimum, ok := float(node["minimum"]); ok && number < minimum {
+		fail("minimum", "value must be >= %v", minimum)
+	}
+	if maximum, ok := float(node["maximum"]); ok && number > maximum {
+		fail("maximum", "value must be <= %v", maximum)
+	}
+	if minimum, ok := float(node["exclusiveMinimum"]); ok && number <= minimum {
+		fail("exclusiveMinimum", "value must be > %v", minimum)
+	}
+	if maximum, ok := float(node["exclusiveMaximum"]); ok && number >= maximum {
+		fail("exclusiveMaximum", "value must be < %v", maximum)
+	}
+}
+
+// matchesType reports whether 'value' has the type, or one of the types, in 'types'.
+func matchesType(types any, value any) bool {
+	switch typed := types.(type) {
+	case string:
+		return typed == typeOf(value) || typed == "number" && typeOf(value) == "integer"
+	case []any:
+		for _, name := range typed {
+			if matchesType(name, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// typeOf returns the JSON Schema type name of 'value'.
+func typeOf(value any) string {
+	switch typed := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	case json.Number:
+		if number, err := typed.Float64(); err == nil && number == math.Trunc(number) {
+			return "integer"
+		}
+		return "number"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// formatTypes formats the type keyword for messages.
+func formatTypes(types any) string {
+	if list, ok := types.([]any); ok {
+		names := make([]string, len(list))
+		for i, name := range list {
+			names[i] = fmt.Sprint(name)
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(types)
+}
+
+// containsJSON reports whether 'values' contains a value equal to 'value'.
+func containsJSON(values []any, value any) bool {
+	for _, candidate := range values {
+		if equalJSON(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// equalJSON compares two decoded JSON values, comparing numbers by value.
+func equalJSON(a, b any) bool {
+	switch typedA := a.(type) {
+	case json.Number:
+		typedB, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		numberA, _ := typedA.Float64()
+		numberB, _ := typedB.Float64()
+		return numberA == numberB
+	case []any:
+		typedB, ok := b.([]any)
+		if !ok || len(typedA) != len(typedB) {
+			return false
+		}
+		for i := range typedA {
+			if !equalJSON(typedA[i], typedB[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		typedB, ok := b.(map[string]any)
+		if !ok || len(typedA) != len(typedB) {
+			return false
+		}
+		for key, valueA := range typedA {
+			valueB, ok := typedB[key]
+			if !ok || !equalJSON(valueA, valueB) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a == b
+	}
+}
+
+// formatJSON formats a decoded JSON value for messages.
+func formatJSON(value any) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// integer returns a schema keyword argument as an int.
+func integer(value any) (int, bool) {
+	number, ok := value.(json.Number)
+	if !ok {
+		return 0, false
+	}
+	result, err := strconv.Atoi(number.String())
+	return result, err == nil
+}
+
+// float returns a schema keyword argument as a float64.
+func float(value any) (float64, bool) {
+	number, ok := value.(json.Number)
+	if !ok {
+		return 0, false
+	}
+	result, err := number.Float64()
+	return result, err == nil
+}
+
+// sortedKeys returns the keys of 'value' in order, so violations are reported deterministically.
+func sortedKeys(value map[string]any) []string {
+	result := make([]string, 0, len(value))
+	for key := range value {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// escapePointer escapes a property name for use in a JSON Pointer (RFC 6901).
+func escapePointer(name string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,146 @@
+package jsonschema
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const userSchema = `{
+	"$defs": {
+		"tag": {"type": "string", "minLength": 2, "maxLength": 5}
+	},
+	"type": "object",
+	"required": ["id", "name"],
+	"properties": {
+		"id": {"type": "integer", "minimum": 1},
+		"name": {"type": "string", "pattern": "^[A-Z]"},
+		"score": {"type": "number", "exclusiveMaximum": 100},
+		"role": {"enum": ["admin", "user"]},
+		"tags": {"type": "array", "maxItems": 2, "items": {"$ref": "#/$defs/tag"}},
+		"a/b": {"const": true}
+	},
+	"additionalProperties": false
+}`
+
+func TestValidate(t *testing.T) {
+	schema := MustCompile([]byte(userSchema))
+	tests := []struct {
+		name     string
+		document string
+		expected []Violation
+	}{
+		{
+			name:     "Valid",
+			document: `{"id": 1, "name": "Ann", "score": 99.5, "role": "admin", "tags": ["go", "json"], "a/b": true}`,
+		},
+		{
+			name:     "Wrong Root Type",
+			document: `[]`,
+			expected: []Violation{{Path: "", Keyword: "type", Message: "expected type object, got array"}},
+		},
+		{
+			name:     "Missing Required",
+			document: `{"name": "Ann"}`,
+			expected: []Violation{{Path: "", Keyword: "required", Message: `missing required property "id"`}},
+		},
+		{
+			name:     "Every Violation",
+			document: `{"name": "ann", "id": 0, "score": 100, "role": "guest", "tags": ["g", "ok", "yes"], "a/b": false, "extra": 1}`,
+			expected: []Violation{
+				{Path: "/a~1b", Keyword: "const", Message: "value must be true"},
+				{Path: "/extra", Keyword: "additionalProperties", Message: "property is not allowed"},
+				{Path: "/id", Keyword: "minimum", Message: "value must be >= 1"},
+				{Path: "/name", Keyword: "pattern", Message: `value does not match pattern "^[A-Z]"`},
+				{Path: "/role", Keyword: "enum", Message: `value must be one of ["admin","user"]`},
+				{Path: "/score", Keyword: "exclusiveMaximum", Message: "value must be < 100"},
+				{Path: "/tags", Keyword: "maxItems", Message: "expected at most 2 items, got 3"},
+				{Path: "/tags/0", Keyword: "minLength", Message: "expected at least 2 characters, got 1"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schema.Validate([]byte(tt.document))
+			if tt.expected == nil {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				return
+			}
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Expected *ValidationError, got %v", err)
+			}
+			if !reflect.DeepEqual(validationErr.Violations, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, validationErr.Violations)
+			}
+		})
+	}
+}
+
+func TestValidateCombinators(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		document string
+		valid    bool
+	}{
+		{name: "AllOf Valid", schema: `{"allOf": [{"type": "string"}, {"minLength": 2}]}`, document: `"ab"`, valid: true},
+		{name: "AllOf Invalid", schema: `{"allOf": [{"type": "string"}, {"minLength": 2}]}`, document: `"a"`, valid: false},
+		{name: "AnyOf Valid", schema: `{"anyOf": [{"type": "string"}, {"type": "null"}]}`, document: `null`, valid: true},
+		{name: "AnyOf Invalid", schema: `{"anyOf": [{"type": "string"}, {"type": "null"}]}`, document: `1`, valid: false},
+		{name: "OneOf Valid", schema: `{"oneOf": [{"type": "integer"}, {"type": "string"}]}`, document: `1`, valid: true},
+		{name: "OneOf Matches Two", schema: `{"oneOf": [{"type": "integer"}, {"type": "number"}]}`, document: `1`, valid: false},
+		{name: "Type List", schema: `{"type": ["string", "null"]}`, document: `null`, valid: true},
+		{name: "True Schema", schema: `true`, document: `{"any": "thing"}`, valid: true},
+		{name: "False Schema", schema: `false`, document: `{}`, valid: false},
+		{name: "Max Length Counts Runes", schema: `{"maxLength": 2}`, document: `"ใช่"`, valid: false},
+		{name: "Recursive Ref", schema: `{"properties": {"child": {"$ref": "#"}}, "required": ["name"]}`, document: `{"name": "a", "child": {"name": "b", "child": {}}}`, valid: false},
+		{name: "Recursive Ref Through Items", schema: `{"anyOf": [{"type": "string"}, {"type": "array", "items": {"$ref": "#"}}]}`, document: `["a", ["b", 1]]`, valid: false},
+		{name: "Shared Ref", schema: `{"$defs": {"a": {"type": "string"}}, "allOf": [{"$ref": "#/$defs/a"}, {"not": {"$ref": "#/$defs/a"}}]}`, document: `"a"`, valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := MustCompile([]byte(tt.schema)).Validate([]byte(tt.document))
+			if (err == nil) != tt.valid {
+				t.Errorf("Expected valid %v, got %v", tt.valid, err)
+			}
+		})
+	}
+}
+
+func TestValidateInvalidJSON(t *testing.T) {
+	err := MustCompile([]byte(`{}`)).Validate([]byte(`{`))
+	var validationErr *ValidationError
+	if err == nil || errors.As(err, &validationErr) {
+		t.Errorf("Expected syntax error, got %v", err)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+	}{
+		{name: "Not JSON", schema: `{`},
+		{name: "Not A Schema", schema: `"string"`},
+		{name: "Invalid Pattern", schema: `{"pattern": "("}`},
+		{name: "Missing Ref", schema: `{"$ref": "#/$defs/missing"}`},
+		{name: "Remote Ref", schema: `{"$ref": "https://example.com/schema.json"}`},
+		{name: "Ref Cycle", schema: `{"$defs": {"a": {"$ref": "#/$defs/b"}, "b": {"$ref": "#/$defs/a"}}, "$ref": "#/$defs/a"}`},
+		{name: "Ref Cycle Through AllOf", schema: `{"allOf": [{"$ref": "#"}]}`},
+		{name: "Ref Cycle Through Defs", schema: `{"$defs": {"a": {"allOf": [{"$ref": "#/$defs/a"}]}}, "$ref": "#/$defs/a"}`},
+		{name: "Ref Cycle Through Not", schema: `{"properties": {"a": {"not": {"anyOf": [{"$ref": "#/properties/a"}]}}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Compile([]byte(tt.schema)); !errors.Is(err, ErrInvalidSchema) {
+				t.Errorf("Expected ErrInvalidSchema, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package json
+
+import (
+	"github.com/guhungry/gohungry/http"
+	"github.com/guhungry/gohungry/http/json/jsonschema"
+)
+
+// WithRequestSchema validates the serialized request body against 'schema' before it is sent.
+// The request fails with a *jsonschema.ValidationError listing every violation.
+func WithRequestSchema[Response any](schema *jsonschema.Schema) http.RequestInfoOption[Response] {
+	return http.WithRequestBodyValidator[Response](schema.Validate)
+}
+
+// WithResponseSchema validates the response body against 'schema' before it is decoded.
+// The request fails with a *jsonschema.ValidationError listing every violation.
+func WithResponseSchema[Response any](schema *jsonschema.Schema) http.RequestInfoOption[Response] {
+	return http.WithResponseBodyValidator[Response](schema.Validate)
+}
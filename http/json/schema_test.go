@@ -0,0 +1,49 @@
+package json
+
+import (
+	"errors"
+	"github.com/guhungry/gohungry/http/httptest"
+	"github.com/guhungry/gohungry/http/json/jsonschema"
+	"testing"
+
+	gohungry "github.com/guhungry/gohungry/http"
+)
+
+var messageSchema = jsonschema.MustCompile([]byte(`{
+	"type": "object",
+	"required": ["message"],
+	"properties": {"message": {"type": "string"}}
+}`))
+
+func TestPostWithSchema(t *testing.T) {
+	tests := []struct {
+		name         string
+		requestBody  any
+		responseBody string
+		expectError  bool
+	}{
+		{name: "Valid", requestBody: map[string]string{"message": "hi"}, responseBody: `{"message":"created"}`},
+		{name: "Invalid Request", requestBody: map[string]int{"message": 1}, responseBody: `{"message":"created"}`, expectError: true},
+		{name: "Invalid Response", requestBody: map[string]string{"message": "hi"}, responseBody: `{"error":"oops"}`, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gohungry.SetHTTPClient(httptest.MockHTTPClientSuccess(201, tt.responseBody))
+			defer gohungry.ResetHTTPClient()
+
+			response, err := Post[MockResponse]("http://example.com", tt.requestBody,
+				WithRequestSchema[MockResponse](messageSchema), WithResponseSchema[MockResponse](messageSchema))
+			var validationErr *jsonschema.ValidationError
+			if tt.expectError {
+				if !errors.As(err, &validationErr) {
+					t.Fatalf("Expected *jsonschema.ValidationError, got %v", err)
+				}
+				return
+			}
+			if err != nil || response.Message != "created" {
+				t.Errorf("Expected created, got %v, %v", response, err)
+			}
+		})
+	}
+}
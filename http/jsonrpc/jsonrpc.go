@@ -0,0 +1,225 @@
+// Package jsonrpc calls JSON-RPC 2.0 services over HTTP with the json package. It provides typed
+// calls, notifications and batches. Request IDs are assigned automatically and error objects
+// are returned as *Error.
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	stdjson "encoding/json"
+	"errors"
+	"fmt"
+	"github.com/guhungry/gohungry/http"
+	"github.com/guhungry/gohungry/http/json"
+	"io"
+	"sync/atomic"
+)
+
+// Version is the protocol version sent in every request.
+const Version = "2.0"
+
+// Error codes defined by the JSON-RPC 2.0 specification
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// Errors returned for responses that do not match their requests
+var (
+	ErrIDMismatch = errors.New("gohungry: JSON-RPC response ID does not match the request")
+	ErrNoResponse = errors.New("gohungry: no JSON-RPC response for the request")
+)
+
+// Error is a JSON-RPC error object.
+type Error struct {
+	Code    int                `json:"code"`
+	Message string             `json:"message"`
+	Data    stdjson.RawMessage `json:"data,omitempty"` // Additional information defined by the server
+}
+
+// Error formats the code and message.
+func (e *Error) Error() string {
+	return fmt.Sprintf("gohungry: JSON-RPC error %d: %s", e.Code, e.Message)
+}
+
+// DecodeData decodes the data of the error into 'v'.
+func (e *Error) DecodeData(v any) error {
+	return stdjson.Unmarshal(e.Data, v)
+}
+
+// Request is a JSON-RPC request object. Notifications have no ID.
+type Request struct {
+	JSONRPC string  `json:"jsonrpc"`
+	Method  string  `json:"method"`
+	Params  any     `json:"params,omitempty"`
+	ID      *uint64 `json:"id,omitempty"`
+}
+
+// Response is a JSON-RPC response object.
+type Response struct {
+	JSONRPC string             `json:"jsonrpc"`
+	Result  stdjson.RawMessage `json:"result,omitempty"`
+	Error   *Error             `json:"error,omitempty"`
+	ID      *uint64            `json:"id"`
+}
+
+// lastID is the ID of the latest request, shared by every call so IDs are unique within the process.
+var lastID atomic.Uint64
+
+// newRequest creates a request for 'method' with the next ID.
+func newRequest(method string, params any) Request {
+	id := lastID.Add(1)
+	return Request{JSONRPC: Version, Method: method, Params: params, ID: &id}
+}
+
+// Call invokes 'method' with 'params' at 'endpoint' and decodes the result into 'Result'.
+// An error object in the response is returned as *Error. 'options' customize the request.
+func Call[Params, Result any](ctx context.Context, endpoint string, method string, params Params, options ...http.RequestInfoOption[Result]) (*Result, error) {
+	request := newRequest(method, params)
+	parser := func(reader io.ReadCloser) (*Result, error) {
+		var response Response
+		if err := stdjson.NewDecoder(reader).Decode(&response); err != nil {
+			return nil, err
+		}
+		if response.Error != nil {
+			return nil, response.Error
+		}
+		if response.ID == nil || *response.ID != *request.ID {
+			return nil, ErrIDMismatch
+		}
+		return decodeResult[Result](response.Result)
+	}
+
+	options = append(options, http.WithContext[Result](ctx), http.WithResponseParser(parser))
+	return json.Post[Result](endpoint, request, options...)
+}
+
+// Notify invokes 'method' with 'params' at 'endpoint' as a notification, which the server does not answer.
+func Notify[Params any](ctx context.Context, endpoint string, method string, params Params, options ...http.RequestInfoOption[Response]) error {
+	request := Request{JSONRPC: Version, Method: method, Params: params}
+	parser := func(reader io.ReadCloser) (*Response, error) {
+		// Servers reply with an empty body, there is nothing to decode
+		_, err := io.Copy(io.Discard, reader)
+		return nil, err
+	}
+
+	options = append(options, http.WithContext[Response](ctx), http.WithResponseParser(parser))
+	_, err := json.Post[Response](endpoint, request, options...)
+	return err
+}
+
+// decodeResult decodes the result of a response into 'Result'.
+func decodeResult[Result any](data stdjson.RawMessage) (*Result, error) {
+	var result Result
+	if len(data) > 0 {
+		if err := stdjson.Unmarshal(data, &result); err != nil {
+			return nil, err
+		}
+	}
+	return &result, nil
+}
+
+// BatchCall is a call added to a Batch. Its result is available after Send.
+type BatchCall struct {
+	request Request
+	result  stdjson.RawMessage
+	err     error
+}
+
+// Decode decodes the result of the call into 'v'. It returns the *Error of the response,
+// or ErrNoResponse when the server did not answer the call.
+func (c *BatchCall) Decode(v any) error {
+	if c.err != nil {
+		return c.err
+	}
+	if len(c.result) == 0 {
+		return nil
+	}
+	return stdjson.Unmarshal(c.result, v)
+}
+
+// BatchResult decodes the result of 'call' into 'Result'.
+func BatchResult[Result any](call *BatchCall) (*Result, error) {
+	var result Result
+	if err := call.Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Batch collects calls and notifications sent together by Send.
+type Batch struct {
+	requests []Request
+	calls    []*BatchCall
+}
+
+// Add adds a call of 'method' with 'params' and returns it to read its result after Send.
+func (b *Batch) Add(method string, params any) *BatchCall {
+	call := &BatchCall{request: newRequest(method, params), err: ErrNoResponse}
+	b.requests = append(b.requests, call.request)
+	b.calls = append(b.calls, call)
+	return call
+}
+
+// Notify adds a notification of 'method' with 'params'.
+func (b *Batch) Notify(method string, params any) {
+	b.requests = append(b.requests, Request{JSONRPC: Version, Method: method, Params: params})
+}
+
+// Send posts the requests of 'batch' to 'endpoint' and matches the responses to the calls by ID.
+// The returned error concerns the whole batch, the result of each call is read with BatchCall.Decode.
+func Send(ctx context.Context, endpoint string, batch *Batch, options ...http.RequestInfoOption[[]Response]) error {
+	parser := func(reader io.ReadCloser) (*[]Response, error) {
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+		var responses []Response
+		trimmed := bytes.TrimSpace(data)
+		switch {
+		case len(trimmed) == 0:
+			// Batches of notifications are not answered
+		case trimmed[0] == '{':
+			// Servers answer with a single error object when the whole batch is rejected
+			var response Response
+			if err := stdjson.Unmarshal(trimmed, &response); err != nil {
+				return nil, err
+			}
+			if response.Error != nil {
+				return nil, response.Error
+			}
+			responses = append(responses, response)
+		default:
+			if err := stdjson.Unmarshal(trimmed, &responses); err != nil {
+				return nil, err
+			}
+		}
+		return &responses, nil
+	}
+
+	options = append(options, http.WithContext[[]Response](ctx), http.WithResponseParser(parser))
+	responses, err := json.Post[[]Response](endpoint, batch.requests, options...)
+	if err != nil {
+		return err
+	}
+
+	calls := make(map[uint64]*BatchCall, len(batch.calls))
+	for _, call := range batch.calls {
+		calls[*call.request.ID] = call
+	}
+	for _, response := range *responses {
+		if response.ID == nil {
+			continue
+		}
+		if call, ok := calls[*response.ID]; ok {
+			call.result, call.err = response.Result, nil
+			if response.Error != nil {
+				call.err = response.Error
+			}
+		}
+	}
+	return nil
+}
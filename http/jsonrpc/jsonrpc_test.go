@@ -0,0 +1,159 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/guhungry/gohungry/http/httptest"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	gohungry "github.com/guhungry/gohungry/http"
+)
+
+// mockRPCClient decodes the request body into 'request' and replies with the result of 'reply'.
+func mockRPCClient(t *testing.T, request any, reply func() string) *httptest.MockHTTPClient {
+	return &httptest.MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if err := json.NewDecoder(req.Body).Decode(request); err != nil {
+				t.Fatal(err)
+			}
+			return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(reply()))}, nil
+		},
+	}
+}
+
+func TestCall(t *testing.T) {
+	var request Request
+	gohungry.SetHTTPClient(mockRPCClient(t, &request, func() string {
+		return fmt.Sprintf(`{"jsonrpc":"2.0","result":"0x10","id":%d}`, *request.ID)
+	}))
+	defer gohungry.ResetHTTPClient()
+
+	first, err := Call[[]any, string](context.Background(), "http://example.com", "eth_getBalance", []any{"0xabc", "latest"})
+	if err != nil || *first != "0x10" {
+		t.Fatalf("Expected 0x10, got %v, %v", first, err)
+	}
+	firstID := *request.ID
+	if request.JSONRPC != "2.0" || request.Method != "eth_getBalance" || fmt.Sprint(request.Params) != "[0xabc latest]" {
+		t.Errorf("Expected eth_getBalance request, got %+v", request)
+	}
+
+	if _, err := Call[[]any, string](context.Background(), "http://example.com", "eth_getBalance", nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if *request.ID != firstID+1 {
+		t.Errorf("Expected ID %d, got %d", firstID+1, *request.ID)
+	}
+}
+
+func TestCallErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		response      func(id uint64) string
+		expectedError error
+	}{
+		{
+			name: "Error Object",
+			response: func(id uint64) string {
+				return fmt.Sprintf(`{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found","data":"eth_foo"},"id":%d}`, id)
+			},
+		},
+		{
+			name:          "ID Mismatch",
+			response:      func(id uint64) string { return fmt.Sprintf(`{"jsonrpc":"2.0","result":"0x10","id":%d}`, id+1) },
+			expectedError: ErrIDMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var request Request
+			gohungry.SetHTTPClient(mockRPCClient(t, &request, func() string { return tt.response(*request.ID) }))
+			defer gohungry.ResetHTTPClient()
+
+			_, err := Call[any, string](context.Background(), "http://example.com", "eth_foo", nil)
+			if tt.expectedError != nil {
+				if !errors.Is(err, tt.expectedError) {
+					t.Errorf("Expected %v, got %v", tt.expectedError, err)
+				}
+				return
+			}
+
+			var rpcErr *Error
+			if !errors.As(err, &rpcErr) || rpcErr.Code != CodeMethodNotFound || rpcErr.Message != "Method not found" {
+				t.Fatalf("Expected method not found error, got %v", err)
+			}
+			var data string
+			if err := rpcErr.DecodeData(&data); err != nil || data != "eth_foo" {
+				t.Errorf("Expected data eth_foo, got %s, %v", data, err)
+			}
+		})
+	}
+}
+
+func TestNotify(t *testing.T) {
+	var request map[string]any
+	gohungry.SetHTTPClient(mockRPCClient(t, &request, func() string { return "" }))
+	defer gohungry.ResetHTTPClient()
+
+	if err := Notify(context.Background(), "http://example.com", "log", map[string]string{"level": "info"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, ok := request["id"]; ok || request["method"] != "log" {
+		t.Errorf("Expected notification without ID, got %v", request)
+	}
+}
+
+func TestSend(t *testing.T) {
+	var requests []Request
+	gohungry.SetHTTPClient(mockRPCClient(t, &requests, func() string {
+		// Answer out of order, skip the notification and leave the last call unanswered
+		return fmt.Sprintf(`[
+			{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params"},"id":%d},
+			{"jsonrpc":"2.0","result":7,"id":%d}
+		]`, *requests[1].ID, *requests[0].ID)
+	}))
+	defer gohungry.ResetHTTPClient()
+
+	batch := &Batch{}
+	sum := batch.Add("sum", []int{3, 4})
+	invalid := batch.Add("sum", "x")
+	batch.Notify("log", nil)
+	unanswered := batch.Add("sum", []int{})
+	if err := Send(context.Background(), "http://example.com", batch); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(requests) != 4 || requests[2].ID != nil {
+		t.Errorf("Expected 3 calls and a notification, got %+v", requests)
+	}
+	if result, err := BatchResult[int](sum); err != nil || *result != 7 {
+		t.Errorf("Expected 7, got %v, %v", result, err)
+	}
+	var rpcErr *Error
+	if _, err := BatchResult[int](invalid); !errors.As(err, &rpcErr) || rpcErr.Code != CodeInvalidParams {
+		t.Errorf("Expected invalid params error, got %v", err)
+	}
+	if _, err := BatchResult[int](unanswered); !errors.Is(err, ErrNoResponse) {
+		t.Errorf("Expected ErrNoResponse, got %v", err)
+	}
+}
+
+func TestSendRejected(t *testing.T) {
+	var requests []Request
+	gohungry.SetHTTPClient(mockRPCClient(t, &requests, func() string {
+		return `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null}`
+	}))
+	defer gohungry.ResetHTTPClient()
+
+	batch := &Batch{}
+	batch.Add("sum", []int{1})
+	var rpcErr *Error
+	if err := Send(context.Background(), "http://example.com", batch); !errors.As(err, &rpcErr) || rpcErr.Code != CodeInvalidRequest {
+		t.Errorf("Expected invalid request error, got %v", err)
+	}
+}
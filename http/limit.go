@@ -0,0 +1,103 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+)
+
+// DefaultMaxResponseBytes is the initial client-wide limit on response body sizes.
+const DefaultMaxResponseBytes int64 = 64 << 20
+
+// ErrResponseTooLarge is matched by errors.Is for every ResponseTooLargeError.
+var ErrResponseTooLarge = errors.New("gohungry: response body too large")
+
+// ResponseTooLargeError is returned when a response body, raw or decompressed, exceeds its limit.
+type ResponseTooLargeError struct {
+	Limit        int64 // Maximum number of bytes allowed
+	Read         int64 // Number of bytes read when the limit was exceeded
+	Decompressed bool  // Whether the decompressed body exceeded the limit, rather than the body as received
+}
+
+// Error describes the exceeded limit.
+func (e *ResponseTooLargeError) Error() string {
+	if e.Decompressed {
+		return fmt.Sprintf("%s: decompressed %d bytes, limit is %d bytes", ErrResponseTooLarge, e.Read, e.Limit)
+	}
+	return fmt.Sprintf("%s: read %d bytes, limit is %d bytes", ErrResponseTooLarge, e.Read, e.Limit)
+}
+
+// Is reports whether 'target' is ErrResponseTooLarge.
+func (e *ResponseTooLargeError) Is(target error) bool {
+	return target == ErrResponseTooLarge
+}
+
+// maxResponseBytes is the client-wide limit applied by NewRequestInfo.
+var maxResponseBytes atomic.Int64
+
+func init() {
+	maxResponseBytes.Store(DefaultMaxResponseBytes)
+}
+
+// SetDefaultMaxResponseBytes sets the client-wide response body limit used by requests created
+// afterwards. Zero or less removes the limit. WithMaxResponseBytes overrides it per request.
+func SetDefaultMaxResponseBytes(n int64) {
+	maxResponseBytes.Store(n)
+}
+
+// WithMaxResponseBytes limits the response body to 'n' bytes, both as received and after
+// decompression. Zero or less removes the limit. Reading more fails with ResponseTooLargeError.
+func WithMaxResponseBytes[Response any](n int64) RequestInfoOption[Response] {
+	return func(c *RequestInfo[Response]) {
+		c.maxResponse = n
+	}
+}
+
+// limitResponseBody fails reading 'body' with ResponseTooLargeError beyond 'limit' bytes.
+// 'decompressed' tells whether 'body' is decompressed. It returns 'body' unchanged when there is no limit.
+func limitResponseBody(body io.ReadCloser, limit int64, decompressed bool) io.ReadCloser {
+	if limit <= 0 {
+		return body
+	}
+	newError := func(limit, read int64) error {
+		return &ResponseTooLargeError{Limit: limit, Read: read, Decompressed: decompressed}
+	}
+	return &readCloser{Reader: newLimitedReader(body, limit, newError), Closer: body}
+}
+
+// readCloser combines a Reader with the Closer of the stream it reads.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// limitedReader reads up to 'limit' bytes from 'reader' and fails with the error from 'err' beyond that.
+type limitedReader struct {
+	reader io.Reader
+	limit  int64
+	read   int64
+	err    func(limit, read int64) error
+}
+
+// newLimitedReader creates a limitedReader for 'reader'.
+func newLimitedReader(reader io.Reader, limit int64, err func(limit, read int64) error) *limitedReader {
+	return &limitedReader{reader: reader, limit: limit, err: err}
+}
+
+// Read reads from the underlying reader, failing once more than 'limit' bytes were read.
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.read > l.limit {
+		return 0, l.err(l.limit, l.read)
+	}
+	// Read one byte past the limit to tell a body of exactly 'limit' bytes from a larger one
+	if remaining := l.limit - l.read + 1; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := l.reader.Read(p)
+	l.read += int64(n)
+	if l.read > l.limit {
+		return n - int(l.read-l.limit), l.err(l.limit, l.read)
+	}
+	return n, err
+}
@@ -0,0 +1,129 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"github.com/guhungry/gohungry/http/httptest"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestLimitedReaderExactLimit(t *testing.T) {
+	reader := limitResponseBody(io.NopCloser(strings.NewReader("12345")), 5, false)
+	data, err := io.ReadAll(reader)
+	if err != nil || string(data) != "12345" {
+		t.Errorf("Expected body at the limit to be read, got %s, %v", data, err)
+	}
+}
+
+func TestResponseTooLargeError(t *testing.T) {
+	err := error(&ResponseTooLargeError{Limit: 10, Read: 11})
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Error("Expected ResponseTooLargeError to match ErrResponseTooLarge")
+	}
+	if err.Error() != "gohungry: response body too large: read 11 bytes, limit is 10 bytes" {
+		t.Errorf("Unexpected error message: %s", err)
+	}
+
+	decompressed := error(&ResponseTooLargeError{Limit: 10, Read: 11, Decompressed: true})
+	if !errors.Is(decompressed, ErrResponseTooLarge) {
+		t.Error("Expected a decompressed body error to match ErrResponseTooLarge")
+	}
+	if decompressed.Error() != "gohungry: response body too large: decompressed 11 bytes, limit is 10 bytes" {
+		t.Errorf("Unexpected error message: %s", decompressed)
+	}
+}
+
+func TestDoRequestMaxResponseBytes(t *testing.T) {
+	large := `{"message":"` + strings.Repeat("x", 1000) + `"}`
+	huge := `{"message":"` + strings.Repeat("x", 1<<20) + `"}`
+	tests := []struct {
+		name                 string
+		body                 []byte
+		encoding             string
+		options              []RequestInfoOption[map[string]string]
+		expectedError        error
+		expectedDecompressed bool
+	}{
+		{
+			name:          "Within Limit",
+			body:          []byte(large),
+			options:       []RequestInfoOption[map[string]string]{WithMaxResponseBytes[map[string]string](int64(len(large)))},
+			expectedError: nil,
+		},
+		{
+			name:          "Raw Body Too Large",
+			body:          []byte(large),
+			options:       []RequestInfoOption[map[string]string]{WithMaxResponseBytes[map[string]string](100)},
+			expectedError: ErrResponseTooLarge,
+		},
+		{
+			name:                 "Decompressed Body Too Large",
+			body:                 compress(t, large, testEncoders["gzip"]),
+			encoding:             "gzip",
+			options:              []RequestInfoOption[map[string]string]{WithMaxResponseBytes[map[string]string](100)},
+			expectedError:        ErrResponseTooLarge,
+			expectedDecompressed: true,
+		},
+		{
+			name:          "Decompressed Body Within Raised Limit",
+			body:          compress(t, huge, testEncoders["gzip"]),
+			encoding:      "gzip",
+			options:       []RequestInfoOption[map[string]string]{WithMaxResponseBytes[map[string]string](2 << 20)},
+			expectedError: nil,
+		},
+		{
+			name:          "Decompressed Body Without Limit",
+			body:          compress(t, huge, testEncoders["gzip"]),
+			encoding:      "gzip",
+			options:       []RequestInfoOption[map[string]string]{WithMaxResponseBytes[map[string]string](0)},
+			expectedError: nil,
+		},
+		{
+			name:          "No Limit",
+			body:          []byte(large),
+			options:       []RequestInfoOption[map[string]string]{WithMaxResponseBytes[map[string]string](0)},
+			expectedError: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetHTTPClient(&httptest.MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					return compressedResponse(tt.body, tt.encoding), nil
+				},
+			})
+			defer ResetHTTPClient()
+
+			requestInfo := NewRequestInfo(MethodGet, "https://example.com", nil, httptest.DummyRequestBodySerializer, httptest.DummyResponseBodyParser[map[string]string], tt.options...)
+			_, err := DoRequest(requestInfo)
+			if !errors.Is(err, tt.expectedError) {
+				t.Fatalf("DoRequest() error = %v, expectedError %v", err, tt.expectedError)
+			}
+
+			var tooLarge *ResponseTooLargeError
+			if errors.As(err, &tooLarge) && (tooLarge.Limit != 100 || tooLarge.Read <= tooLarge.Limit || tooLarge.Decompressed != tt.expectedDecompressed) {
+				t.Errorf("Expected limit 100, more bytes read and decompressed %v, got %+v", tt.expectedDecompressed, tooLarge)
+			}
+		})
+	}
+}
+
+func TestSetDefaultMaxResponseBytes(t *testing.T) {
+	SetDefaultMaxResponseBytes(10)
+	defer SetDefaultMaxResponseBytes(DefaultMaxResponseBytes)
+	SetHTTPClient(&httptest.MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(bytes.NewBufferString(`{"message":"success"}`))}, nil
+		},
+	})
+	defer ResetHTTPClient()
+
+	requestInfo := NewRequestInfo(MethodGet, "https://example.com", nil, httptest.DummyRequestBodySerializer, httptest.DummyResponseBodyParser[map[string]string])
+	if _, err := DoRequest(requestInfo); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("Expected ErrResponseTooLarge from the client-wide limit, got %v", err)
+	}
+}
@@ -0,0 +1,353 @@
+// Package loadbalance spreads requests across the replicas of an upstream without a load balancer
+// in front. A Balancer rewrites each request to one of its endpoints, given as base URLs or found
+// by a Resolver, with a round-robin, random or least-outstanding Policy. Endpoints that fail to
+// connect are ejected for a while (passive health checking), and idempotent requests fail over to
+// another endpoint. Attach a Balancer with gohungry's WithMiddleware client option.
+package loadbalance
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	gohungry "github.com/guhungry/gohungry/http"
+)
+
+// ErrNoEndpoints is returned when the Balancer has no endpoint to send a request to.
+var ErrNoEndpoints = errors.New("gohungry: no load balancer endpoints")
+
+// Policy selects the endpoint of each request.
+type Policy int
+
+// Load balancing policies
+const (
+	RoundRobin       Policy = iota // Endpoints in turn, the default
+	Random                         // A random endpoint
+	LeastOutstanding               // The endpoint with the fewest requests in flight
+)
+
+// Default balancer settings
+const (
+	DefaultEjectDuration   = 30 * time.Second // Time an endpoint is skipped after a connection failure
+	DefaultResolveInterval = 30 * time.Second // Time the endpoints of a Resolver are kept before resolving again
+	DefaultResolveTimeout  = 10 * time.Second // Time a Resolver is given to return the endpoints
+)
+
+// Resolver returns the base URLs of the endpoints, e.g. from DNS or service discovery.
+// 'ctx' is not cancelled with the request that triggered the resolution, but ends after DefaultResolveTimeout.
+type Resolver func(ctx context.Context) ([]string, error)
+
+// Endpoint is an upstream replica.
+type Endpoint struct {
+	URL          *url.URL
+	outstanding  atomic.Int64
+	ejectedUntil atomic.Int64 // Unix nanoseconds until which the endpoint is skipped
+}
+
+// Outstanding returns the number of requests in flight to the endpoint.
+func (e *Endpoint) Outstanding() int64 {
+	return e.outstanding.Load()
+}
+
+// Healthy reports whether the endpoint is not ejected.
+func (e *Endpoint) Healthy() bool {
+	return time.Now().UnixNano() >= e.ejectedUntil.Load()
+}
+
+// Balancer distributes requests across endpoints.
+type Balancer struct {
+	policy          Policy
+	ejectDuration   time.Duration
+	resolver        Resolver
+	resolveInterval time.Duration
+	next            atomic.Uint64 // Round-robin counter
+
+	mu         sync.Mutex
+	endpoints  []*Endpoint
+	resolvedAt time.Time
+	resolving  chan struct{} // Closed when the resolution in progress ends
+	resolveErr error         // Error of the last resolution
+}
+
+// Option modifies a Balancer.
+type Option func(b *Balancer)
+
+// WithPolicy sets how endpoints are selected. Defaults to RoundRobin.
+func WithPolicy(policy Policy) Option {
+	return func(b *Balancer) {
+		b.policy = policy
+	}
+}
+
+// WithEjectDuration sets how long an endpoint is skipped after a connection failure.
+// Defaults to DefaultEjectDuration.
+func WithEjectDuration(duration time.Duration) Option {
+	return func(b *Balancer) {
+		b.ejectDuration = duration
+	}
+}
+
+// WithResolveInterval sets how long the endpoints of a Resolver are kept. Defaults to DefaultResolveInterval.
+func WithResolveInterval(interval time.Duration) Option {
+	return func(b *Balancer) {
+		b.resolveInterval = interval
+	}
+}
+
+// New creates a Balancer over the endpoints at 'baseURLs', such as "http://10.0.0.1:8080/api".
+func New(baseURLs []string, options ...Option) (*Balancer, error) {
+	result := newBalancer(options)
+	endpoints, err := result.toEndpoints(baseURLs)
+	if err != nil {
+		return nil, err
+	}
+	result.endpoints = endpoints
+	return result, nil
+}
+
+// NewWithResolver creates a Balancer over the endpoints returned by 'resolver'. It resolves on the
+// first request and again once the endpoints are older than the resolve interval. Only the request
+// that starts a resolution waits for it, the others keep using the previous endpoints. When resolving
+// fails, the previous endpoints stay in use.
+func NewWithResolver(resolver Resolver, options ...Option) *Balancer {
+	result := newBalancer(options)
+	result.resolver = resolver
+	return result
+}
+
+// newBalancer creates a Balancer configured with 'options'.
+func newBalancer(options []Option) *Balancer {
+	result := &Balancer{ejectDuration: DefaultEjectDuration, resolveInterval: DefaultResolveInterval}
+	for _, option := range options {
+		option(result)
+	}
+	return result
+}
+
+// toEndpoints parses 'baseURLs', keeping the state of the endpoints already known.
+func (b *Balancer) toEndpoints(baseURLs []string) ([]*Endpoint, error) {
+	known := make(map[string]*Endpoint, len(b.endpoints))
+	for _, endpoint := range b.endpoints {
+		known[endpoint.URL.String()] = endpoint
+	}
+
+	result := make([]*Endpoint, 0, len(baseURLs))
+	for _, baseURL := range baseURLs {
+		parsed, err := url.Parse(baseURL)
+		if err != nil {
+			return nil, err
+		}
+		if endpoint, ok := known[parsed.String()]; ok {
+			result = append(result, endpoint)
+		} else {
+			result = append(result, &Endpoint{URL: parsed})
+		}
+	}
+	return result, nil
+}
+
+// Endpoints returns the current endpoints.
+func (b *Balancer) Endpoints() []*Endpoint {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.endpoints
+}
+
+// Middleware sends each request of a client to an endpoint of the Balancer.
+// The scheme and host of the request are replaced by those of the endpoint, and its path is
+// appended to the path of the endpoint.
+func (b *Balancer) Middleware() gohungry.Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return gohungry.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return b.roundTrip(next, req)
+		})
+	}
+}
+
+// roundTrip sends 'req' to an endpoint, failing over to the others for idempotent requests.
+func (b *Balancer) roundTrip(next http.RoundTripper, req *http.Request) (*http.Response, error) {
+	endpoints, err := b.currentEndpoints(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	idempotent := gohungry.IsIdempotent(req)
+	tried := make(map[*Endpoint]bool, len(endpoints))
+	var lastErr error
+	for len(tried) < len(endpoints) {
+		endpoint := b.pick(endpoints, tried)
+		tried[endpoint] = true
+
+		attempt, err := toEndpoint(req, endpoint, len(tried) > 1)
+		if err != nil {
+			return nil, err
+		}
+		endpoint.outstanding.Add(1)
+		res, err := next.RoundTrip(attempt)
+		if err == nil {
+			endpoint.ejectedUntil.Store(0)
+			res.Body = &trackedBody{ReadCloser: res.Body, endpoint: endpoint}
+			return res, nil
+		}
+		endpoint.outstanding.Add(-1)
+
+		if req.Context().Err() != nil {
+			// The caller gave up, the endpoint is not to blame
+			return nil, err
+		}
+		if isConnectionFailure(err) {
+			endpoint.ejectedUntil.Store(time.Now().Add(b.ejectDuration).UnixNano())
+		}
+		lastErr = err
+		if !idempotent {
+			return nil, err
+		}
+	}
+	return nil, lastErr
+}
+
+// isConnectionFailure reports whether 'err' shows that the endpoint could not be reached or dropped
+// the connection, rather than a slow response or a cancelled request.
+func isConnectionFailure(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return opErr.Op == "dial" || !opErr.Timeout()
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// currentEndpoints returns the endpoints, resolving them when they are missing or stale.
+func (b *Balancer) currentEndpoints(ctx context.Context) ([]*Endpoint, error) {
+	b.mu.Lock()
+	started := false
+	if b.resolver != nil && b.resolving == nil && (b.endpoints == nil || time.Since(b.resolvedAt) >= b.resolveInterval) {
+		b.resolving, started = make(chan struct{}), true
+		go b.resolve(ctx, b.resolving)
+	}
+	done := b.resolving
+	if !started && len(b.endpoints) > 0 {
+		// Another request is resolving, the current endpoints are used meanwhile
+		done = nil
+	}
+	b.mu.Unlock()
+
+	if done != nil {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.endpoints) == 0 {
+		if b.resolveErr != nil {
+			return nil, b.resolveErr
+		}
+		return nil, ErrNoEndpoints
+	}
+	return b.endpoints, nil
+}
+
+// resolve replaces the endpoints with those of the resolver and closes 'done'. The resolver runs
+// without the lock and without the cancellation of 'ctx', so neither a slow resolver nor a caller
+// giving up holds back the other requests.
+func (b *Balancer) resolve(ctx context.Context, done chan struct{}) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), DefaultResolveTimeout)
+	defer cancel()
+	baseURLs, err := b.resolver(ctx)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil {
+		var endpoints []*Endpoint
+		if endpoints, err = b.toEndpoints(baseURLs); err == nil {
+			b.endpoints, b.resolvedAt = endpoints, time.Now()
+		}
+	}
+	b.resolveErr = err
+	b.resolving = nil
+	close(done)
+}
+
+// pick selects an endpoint not in 'tried' with the policy, preferring healthy ones.
+// When every remaining endpoint is ejected, they are all considered.
+func (b *Balancer) pick(endpoints []*Endpoint, tried map[*Endpoint]bool) *Endpoint {
+	var healthy, remaining []*Endpoint
+	for _, endpoint := range endpoints {
+		if tried[endpoint] {
+			continue
+		}
+		remaining = append(remaining, endpoint)
+		if endpoint.Healthy() {
+			healthy = append(healthy, endpoint)
+		}
+	}
+	candidates := healthy
+	if len(candidates) == 0 {
+		candidates = remaining
+	}
+
+	switch b.policy {
+	case Random:
+		return candidates[rand.IntN(len(candidates))]
+	case LeastOutstanding:
+		result := candidates[0]
+		for _, endpoint := range candidates[1:] {
+			if endpoint.Outstanding() < result.Outstanding() {
+				result = endpoint
+			}
+		}
+		return result
+	default:
+		return candidates[int(b.next.Add(1)-1)%len(candidates)]
+	}
+}
+
+// toEndpoint clones 'req' with the URL rewritten to 'endpoint'. Failovers replay the body through GetBody.
+func toEndpoint(req *http.Request, endpoint *Endpoint, failover bool) (*http.Request, error) {
+	result := req.Clone(req.Context())
+	if failover && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		result.Body = body
+	}
+
+	result.URL.Scheme = endpoint.URL.Scheme
+	result.URL.Host = endpoint.URL.Host
+	result.URL.Path = strings.TrimSuffix(endpoint.URL.Path, "/") + req.URL.Path
+	if req.URL.RawPath != "" {
+		result.URL.RawPath = strings.TrimSuffix(endpoint.URL.EscapedPath(), "/") + req.URL.RawPath
+	}
+	result.Host = ""
+	return result, nil
+}
+
+// trackedBody counts a request as outstanding until its response body is closed.
+type trackedBody struct {
+	io.ReadCloser
+	endpoint *Endpoint
+	closed   atomic.Bool
+}
+
+// Close closes the body and ends the request.
+func (t *trackedBody) Close() error {
+	if t.closed.CompareAndSwap(false, true) {
+		t.endpoint.outstanding.Add(-1)
+	}
+	return t.ReadCloser.Close()
+}
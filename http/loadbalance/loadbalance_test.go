@@ -0,0 +1,258 @@
+package loadbalance
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	nethttptest "net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	gohungry "github.com/guhungry/gohungry/http"
+)
+
+// newServer starts a server answering its 'name' and the request path.
+func newServer(t *testing.T, name string) *nethttptest.Server {
+	server := nethttptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(name + " " + r.URL.Path))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// send sends a request through 'balancer' and returns the body of the response.
+func send(t *testing.T, balancer *Balancer, method string) (string, error) {
+	t.Helper()
+	client, _ := gohungry.NewClient(gohungry.WithMiddleware(balancer.Middleware()))
+	req, _ := http.NewRequest(method, "http://users-api/users/1", strings.NewReader("body"))
+	res, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	return string(body), err
+}
+
+func TestRoundRobin(t *testing.T) {
+	a, b := newServer(t, "a"), newServer(t, "b")
+	balancer, err := New([]string{a.URL, b.URL + "/v2/"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var bodies []string
+	for range 4 {
+		body, err := send(t, balancer, http.MethodGet)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		bodies = append(bodies, body)
+	}
+	expected := "a /users/1,b /v2/users/1,a /users/1,b /v2/users/1"
+	if strings.Join(bodies, ",") != expected {
+		t.Errorf("Expected %s, got %s", expected, strings.Join(bodies, ","))
+	}
+}
+
+func TestRandom(t *testing.T) {
+	a, b := newServer(t, "a"), newServer(t, "b")
+	balancer, _ := New([]string{a.URL, b.URL}, WithPolicy(Random))
+
+	seen := make(map[string]bool)
+	for range 50 {
+		body, err := send(t, balancer, http.MethodGet)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		seen[body] = true
+	}
+	if len(seen) != 2 {
+		t.Errorf("Expected both endpoints to be used, got %v", seen)
+	}
+}
+
+func TestLeastOutstanding(t *testing.T) {
+	a, b := newServer(t, "a"), newServer(t, "b")
+	balancer, _ := New([]string{a.URL, b.URL}, WithPolicy(LeastOutstanding))
+
+	// Keep a request to the first endpoint in flight by not closing its body
+	client, _ := gohungry.NewClient(gohungry.WithMiddleware(balancer.Middleware()))
+	res, err := client.Get("http://users-api/held")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if balancer.Endpoints()[0].Outstanding() != 1 {
+		t.Fatalf("Expected 1 outstanding request, got %d", balancer.Endpoints()[0].Outstanding())
+	}
+
+	for range 2 {
+		if body, _ := send(t, balancer, http.MethodGet); body != "b /users/1" {
+			t.Errorf("Expected the idle endpoint, got %s", body)
+		}
+	}
+	res.Body.Close()
+	if balancer.Endpoints()[0].Outstanding() != 0 {
+		t.Errorf("Expected no outstanding request after close, got %d", balancer.Endpoints()[0].Outstanding())
+	}
+}
+
+func TestFailover(t *testing.T) {
+	down, up := newServer(t, "down"), newServer(t, "up")
+	down.Close()
+
+	tests := []struct {
+		name        string
+		method      string
+		expectError bool
+	}{
+		{name: "Idempotent", method: http.MethodPut, expectError: false},
+		{name: "Not Idempotent", method: http.MethodPost, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			balancer, _ := New([]string{down.URL, up.URL})
+			body, err := send(t, balancer, tt.method)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("Expected error %v, got %s, %v", tt.expectError, body, err)
+			}
+			if !tt.expectError && body != "up /users/1" {
+				t.Errorf("Expected failover to the healthy endpoint, got %s", body)
+			}
+			if balancer.Endpoints()[0].Healthy() {
+				t.Error("Expected the failed endpoint to be ejected")
+			}
+
+			// The ejected endpoint is skipped while another is healthy
+			if body, err := send(t, balancer, tt.method); err != nil || body != "up /users/1" {
+				t.Errorf("Expected the healthy endpoint, got %s, %v", body, err)
+			}
+		})
+	}
+}
+
+func TestEjection(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		expectEjected bool
+	}{
+		{name: "Dial Failure", err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, expectEjected: true},
+		{name: "Connection Reset", err: &net.OpError{Op: "read", Err: errors.New("connection reset by peer")}, expectEjected: true},
+		{name: "Connection Closed", err: io.EOF, expectEjected: true},
+		{name: "Read Timeout", err: &net.OpError{Op: "read", Err: os.ErrDeadlineExceeded}, expectEjected: false},
+		{name: "Response Header Timeout", err: errors.New("net/http: timeout awaiting response headers"), expectEjected: false},
+		{name: "Deadline Exceeded", err: context.DeadlineExceeded, expectEjected: false},
+		{name: "Cancelled", err: context.Canceled, expectEjected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			balancer, _ := New([]string{"http://10.0.0.1"})
+			transport := balancer.Middleware()(gohungry.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				return nil, tt.err
+			}))
+			req, _ := http.NewRequest(http.MethodGet, "http://users-api/users/1", nil)
+			if _, err := transport.RoundTrip(req); !errors.Is(err, tt.err) {
+				t.Fatalf("Expected %v, got %v", tt.err, err)
+			}
+			if ejected := !balancer.Endpoints()[0].Healthy(); ejected != tt.expectEjected {
+				t.Errorf("Expected ejected %v, got %v", tt.expectEjected, ejected)
+			}
+		})
+	}
+}
+
+func TestResolver(t *testing.T) {
+	a, b := newServer(t, "a"), newServer(t, "b")
+	errResolve := errors.New("resolve failed")
+	results := []struct {
+		urls []string
+		err  error
+	}{
+		{urls: []string{a.URL}},
+		{err: errResolve},
+		{urls: []string{b.URL}},
+	}
+	calls := 0
+	resolver := func(ctx context.Context) ([]string, error) {
+		result := results[min(calls, len(results)-1)]
+		calls++
+		return result.urls, result.err
+	}
+	balancer := NewWithResolver(resolver, WithResolveInterval(time.Nanosecond))
+
+	// The failed resolution keeps the previous endpoints
+	for _, expected := range []string{"a /users/1", "a /users/1", "b /users/1"} {
+		if body, err := send(t, balancer, http.MethodGet); err != nil || body != expected {
+			t.Errorf("Expected %s, got %s, %v", expected, body, err)
+		}
+	}
+}
+
+func TestNoEndpoints(t *testing.T) {
+	balancer, _ := New(nil)
+	if _, err := send(t, balancer, http.MethodGet); !errors.Is(err, ErrNoEndpoints) {
+		t.Errorf("Expected ErrNoEndpoints, got %v", err)
+	}
+}
+
+func TestSlowResolver(t *testing.T) {
+	a := newServer(t, "a")
+	resolving, release := make(chan struct{}), make(chan struct{})
+	calls := 0
+	resolver := func(ctx context.Context) ([]string, error) {
+		calls++
+		if calls > 1 {
+			close(resolving)
+			<-release
+		}
+		return []string{a.URL}, nil
+	}
+	balancer := NewWithResolver(resolver, WithResolveInterval(time.Nanosecond))
+	if _, err := send(t, balancer, http.MethodGet); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The request starting the resolution waits for it, the others use the current endpoints
+	waiting := make(chan error, 1)
+	go func() {
+		_, err := send(t, balancer, http.MethodGet)
+		waiting <- err
+	}()
+	<-resolving
+	if body, err := send(t, balancer, http.MethodGet); err != nil || body != "a /users/1" {
+		t.Errorf("Expected the current endpoint during the resolution, got %s, %v", body, err)
+	}
+
+	close(release)
+	if err := <-waiting; err != nil {
+		t.Errorf("Expected no error after the resolution, got %v", err)
+	}
+}
+
+func TestResolverCancelled(t *testing.T) {
+	a := newServer(t, "a")
+	release := make(chan struct{})
+	resolver := func(ctx context.Context) ([]string, error) {
+		<-release
+		return []string{a.URL}, ctx.Err()
+	}
+	balancer := NewWithResolver(resolver)
+
+	// A caller giving up does not cancel the resolution shared with the others
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := balancer.currentEndpoints(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	close(release)
+	if body, err := send(t, balancer, http.MethodGet); err != nil || body != "a /users/1" {
+		t.Errorf("Expected the resolved endpoint, got %s, %v", body, err)
+	}
+}
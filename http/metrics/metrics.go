@@ -0,0 +1,287 @@
+// Package metrics records the requests of DoRequest without external dependencies. A Collector is a
+// gohungry Hook that counts requests and measures their durations and response sizes, and an
+// http.Handler that serves them in the Prometheus text exposition format.
+//
+// Series are labelled by method, host, route template and status class. Set the route template
+// with gohungry's WithRoute; requests without one share an empty route label.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	gohungry "github.com/guhungry/gohungry/http"
+)
+
+// ContentType is the media type of the Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultNamespace prefixes the metric names.
+const DefaultNamespace = "gohungry"
+
+// Default histogram buckets
+var (
+	DefaultDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10} // Seconds
+	DefaultSizeBuckets     = []float64{100, 1_000, 10_000, 100_000, 1_000_000, 10_000_000}      // Bytes
+)
+
+// series identifies a request series by its labels.
+type series struct {
+	method      string
+	host        string
+	route       string
+	statusClass string
+}
+
+// target identifies the in-flight requests, whose status is not yet known.
+type target struct {
+	method string
+	host   string
+	route  string
+}
+
+// histogram counts observations per bucket, without accumulating across buckets.
+type histogram struct {
+	counts []uint64 // One count per bucket, plus one for +Inf
+	sum    float64
+	count  uint64
+}
+
+// observe adds 'value' to the bucket it falls in.
+func (h *histogram) observe(buckets []float64, value float64) {
+	h.counts[sort.SearchFloat64s(buckets, value)]++
+	h.sum += value
+	h.count++
+}
+
+// Collector records request metrics and serves them over HTTP. Use New to create it.
+type Collector struct {
+	namespace       string
+	durationBuckets []float64
+	sizeBuckets     []float64
+
+	mu        sync.Mutex
+	inFlight  map[target]int64
+	requests  map[series]uint64
+	durations map[series]*histogram
+	sizes     map[series]*histogram
+}
+
+// Option modifies a Collector.
+type Option func(c *Collector)
+
+// WithNamespace prefixes the metric names with 'namespace' instead of DefaultNamespace.
+func WithNamespace(namespace string) Option {
+	return func(c *Collector) {
+		c.namespace = namespace
+	}
+}
+
+// WithDurationBuckets sets the upper bounds, in seconds, of the request duration histogram.
+func WithDurationBuckets(buckets ...float64) Option {
+	return func(c *Collector) {
+		c.durationBuckets = sortedBuckets(buckets)
+	}
+}
+
+// WithSizeBuckets sets the upper bounds, in bytes, of the response size histogram.
+func WithSizeBuckets(buckets ...float64) Option {
+	return func(c *Collector) {
+		c.sizeBuckets = sortedBuckets(buckets)
+	}
+}
+
+// New creates a Collector configured with 'options'. Register it with gohungry's SetHooks or WithHook.
+func New(options ...Option) *Collector {
+	c := &Collector{
+		namespace:       DefaultNamespace,
+		durationBuckets: DefaultDurationBuckets,
+		sizeBuckets:     DefaultSizeBuckets,
+		inFlight:        make(map[target]int64),
+		requests:        make(map[series]uint64),
+		durations:       make(map[series]*histogram),
+		sizes:           make(map[series]*histogram),
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// RequestStart counts the request as in flight.
+func (c *Collector) RequestStart(ctx context.Context, event *gohungry.RequestEvent) context.Context {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inFlight[newTarget(event)]++
+	return ctx
+}
+
+// RequestSend does nothing, the Collector has no headers to add.
+func (c *Collector) RequestSend(context.Context, *http.Request) {}
+
+// StageEnd does nothing, stage durations are part of the request duration.
+func (c *Collector) StageEnd(context.Context, gohungry.Stage, time.Time, error) {}
+
+// RequestEnd counts the request and records its duration and response size.
+func (c *Collector) RequestEnd(ctx context.Context, event *gohungry.RequestEvent) {
+	t := newTarget(event)
+	s := series{method: t.method, host: t.host, route: t.route, statusClass: statusClass(event.StatusCode)}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.inFlight[t]--; c.inFlight[t] == 0 {
+		delete(c.inFlight, t)
+	}
+	c.requests[s]++
+	if c.durations[s] == nil {
+		c.durations[s] = &histogram{counts: make([]uint64, len(c.durationBuckets)+1)}
+		c.sizes[s] = &histogram{counts: make([]uint64, len(c.sizeBuckets)+1)}
+	}
+	c.durations[s].observe(c.durationBuckets, event.Duration.Seconds())
+	c.sizes[s].observe(c.sizeBuckets, float64(event.ResponseSize))
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set(gohungry.HeaderContentType, ContentType)
+	_, _ = c.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format to 'w'.
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	c.mu.Lock()
+	c.writeInFlight(&b)
+	c.writeRequests(&b)
+	c.writeHistograms(&b, "request_duration_seconds", "Duration of HTTP client requests in seconds.", c.durationBuckets, c.durations)
+	c.writeHistograms(&b, "response_size_bytes", "Size of HTTP client response bodies in bytes.", c.sizeBuckets, c.sizes)
+	c.mu.Unlock()
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// writeInFlight writes the gauge of in-flight requests.
+func (c *Collector) writeInFlight(b *strings.Builder) {
+	name := c.writeHeader(b, "requests_in_flight", "gauge", "HTTP client requests waiting for a response.")
+	targets := make([]target, 0, len(c.inFlight))
+	for t := range c.inFlight {
+		targets = append(targets, t)
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].key() < targets[j].key() })
+	for _, t := range targets {
+		fmt.Fprintf(b, "%s{%s} %d\n", name, t.labels(), c.inFlight[t])
+	}
+}
+
+// writeRequests writes the counter of completed requests.
+func (c *Collector) writeRequests(b *strings.Builder) {
+	name := c.writeHeader(b, "requests_total", "counter", "HTTP client requests completed.")
+	for _, s := range sortedSeries(c.requests) {
+		fmt.Fprintf(b, "%s{%s} %d\n", name, s.labels(), c.requests[s])
+	}
+}
+
+// writeHistograms writes the histogram 'suffix', with cumulative bucket counts.
+func (c *Collector) writeHistograms(b *strings.Builder, suffix, help string, buckets []float64, histograms map[series]*histogram) {
+	name := c.writeHeader(b, suffix, "histogram", help)
+	for _, s := range sortedSeries(histograms) {
+		h, labels := histograms[s], s.labels()
+		var cumulative uint64
+		for i, count := range h.counts {
+			cumulative += count
+			le := "+Inf"
+			if i < len(buckets) {
+				le = formatFloat(buckets[i])
+			}
+			fmt.Fprintf(b, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, le, cumulative)
+		}
+		fmt.Fprintf(b, "%s_sum{%s} %s\n", name, labels, formatFloat(h.sum))
+		fmt.Fprintf(b, "%s_count{%s} %d\n", name, labels, h.count)
+	}
+}
+
+// writeHeader writes the HELP and TYPE lines of a metric and returns its full name.
+func (c *Collector) writeHeader(b *strings.Builder, suffix, kind, help string) string {
+	name := "http_client_" + suffix
+	if c.namespace != "" {
+		name = c.namespace + "_" + name
+	}
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	return name
+}
+
+// newTarget returns the labels of 'event' known before the response.
+func newTarget(event *gohungry.RequestEvent) target {
+	host := ""
+	if parsed, err := url.Parse(event.URL); err == nil {
+		host = parsed.Host
+	}
+	return target{method: gohungry.TelemetryMethod(event.Method), host: host, route: event.Route}
+}
+
+// key orders the in-flight targets.
+func (t target) key() string {
+	return t.method + "\x00" + t.host + "\x00" + t.route
+}
+
+// labels formats the labels of the target.
+func (t target) labels() string {
+	return fmt.Sprintf(`method="%s",host="%s",route="%s"`, escape(t.method), escape(t.host), escape(t.route))
+}
+
+// key orders the series.
+func (s series) key() string {
+	return s.method + "\x00" + s.host + "\x00" + s.route + "\x00" + s.statusClass
+}
+
+// labels formats the labels of the series.
+func (s series) labels() string {
+	return fmt.Sprintf(`method="%s",host="%s",route="%s",status_class="%s"`, escape(s.method), escape(s.host), escape(s.route), s.statusClass)
+}
+
+// sortedSeries returns the series of 'values' in a stable order.
+func sortedSeries[V any](values map[series]V) []series {
+	result := make([]series, 0, len(values))
+	for s := range values {
+		result = append(result, s)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].key() < result[j].key() })
+	return result
+}
+
+// sortedBuckets returns a sorted copy of 'buckets'.
+func sortedBuckets(buckets []float64) []float64 {
+	result := append([]float64(nil), buckets...)
+	sort.Float64s(result)
+	return result
+}
+
+// statusClass returns the class of 'statusCode', e.g. "2xx", or "error" when no response was received.
+func statusClass(statusCode int) string {
+	if statusCode < 100 || statusCode > 599 {
+		return "error"
+	}
+	return strconv.Itoa(statusCode/100) + "xx"
+}
+
+// formatFloat formats 'value' as a Prometheus sample value.
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// labelEscaper escapes label values as required by the text exposition format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escape escapes a label value.
+func escape(value string) string {
+	return labelEscaper.Replace(value)
+}
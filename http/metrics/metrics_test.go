@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	nethttptest "net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gohungry "github.com/guhungry/gohungry/http"
+	"github.com/guhungry/gohungry/http/httptest"
+)
+
+// scrape returns the metrics served by 'collector'.
+func scrape(t *testing.T, collector *Collector) string {
+	t.Helper()
+	recorder := nethttptest.NewRecorder()
+	collector.ServeHTTP(recorder, nethttptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if contentType := recorder.Header().Get(gohungry.HeaderContentType); contentType != ContentType {
+		t.Errorf("Expected Content-Type %s, got %s", ContentType, contentType)
+	}
+	return recorder.Body.String()
+}
+
+func TestCollector(t *testing.T) {
+	server := nethttptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/0") {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		_, _ = w.Write([]byte(`{"message":"success"}`))
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	collector := New(WithDurationBuckets(10, 0.5), WithSizeBuckets(10, 100))
+	for _, path := range []string{"/users/1", "/users/2", "/users/0"} {
+		requestInfo := gohungry.NewRequestInfo(gohungry.MethodGet, server.URL+path, nil, httptest.DummyRequestBodySerializer, httptest.DummyResponseBodyParser[map[string]string],
+			gohungry.WithHook[map[string]string](collector), gohungry.WithRoute[map[string]string]("/users/{id}"))
+		_, _ = gohungry.DoRequest(requestInfo)
+	}
+	labels := `method="GET",host="` + host + `",route="/users/{id}"`
+
+	output := scrape(t, collector)
+	expected := []string{
+		"# TYPE gohungry_http_client_requests_in_flight gauge\n# HELP",
+		"# TYPE gohungry_http_client_requests_total counter\n",
+		"gohungry_http_client_requests_total{" + labels + `,status_class="2xx"} 2` + "\n",
+		"gohungry_http_client_requests_total{" + labels + `,status_class="4xx"} 1` + "\n",
+		"# TYPE gohungry_http_client_request_duration_seconds histogram\n",
+		"gohungry_http_client_request_duration_seconds_bucket{" + labels + `,status_class="2xx",le="0.5"} 2` + "\n",
+		"gohungry_http_client_request_duration_seconds_bucket{" + labels + `,status_class="2xx",le="+Inf"} 2` + "\n",
+		"gohungry_http_client_request_duration_seconds_count{" + labels + `,status_class="2xx"} 2` + "\n",
+		"gohungry_http_client_response_size_bytes_bucket{" + labels + `,status_class="2xx",le="10"} 0` + "\n",
+		"gohungry_http_client_response_size_bytes_bucket{" + labels + `,status_class="2xx",le="100"} 2` + "\n",
+		"gohungry_http_client_response_size_bytes_sum{" + labels + `,status_class="2xx"} 42` + "\n",
+	}
+	for _, line := range expected {
+		if !strings.Contains(output, line) {
+			t.Errorf("Expected output to contain %q, got\n%s", line, output)
+		}
+	}
+	if strings.Contains(output, "/users/1") {
+		t.Errorf("Expected the route template instead of the path, got\n%s", output)
+	}
+}
+
+func TestCollectorInFlight(t *testing.T) {
+	collector := New(WithNamespace("app"))
+	event := &gohungry.RequestEvent{Method: "PURGE", URL: "https://example.com/cache"}
+	collector.RequestStart(context.Background(), event)
+
+	inFlight := `app_http_client_requests_in_flight{method="_OTHER",host="example.com",route=""} 1`
+	if output := scrape(t, collector); !strings.Contains(output, inFlight) {
+		t.Errorf("Expected output to contain %q, got\n%s", inFlight, output)
+	}
+
+	event.Duration, event.Err = time.Second, context.DeadlineExceeded
+	collector.RequestEnd(context.Background(), event)
+	output := scrape(t, collector)
+	if strings.Contains(output, inFlight) || !strings.Contains(output, `status_class="error"} 1`) {
+		t.Errorf("Expected no request in flight and one error, got\n%s", output)
+	}
+}
+
+func TestStatusClass(t *testing.T) {
+	tests := []struct {
+		statusCode int
+		expected   string
+	}{
+		{statusCode: 0, expected: "error"},
+		{statusCode: http.StatusOK, expected: "2xx"},
+		{statusCode: http.StatusFound, expected: "3xx"},
+		{statusCode: http.StatusServiceUnavailable, expected: "5xx"},
+	}
+
+	for _, tt := range tests {
+		if actual := statusClass(tt.statusCode); actual != tt.expected {
+			t.Errorf("Expected %s for %d, got %s", tt.expected, tt.statusCode, actual)
+		}
+	}
+}
+
+func TestEscape(t *testing.T) {
+	if actual := escape("a\"b\\c\nd"); actual != `a\"b\\c\nd` {
+		t.Errorf("Expected escaped label value, got %s", actual)
+	}
+}
@@ -0,0 +1,61 @@
+package http
+
+import (
+	"context"
+	"net/http"
+)
+
+// RoundTripperFunc adapts an ordinary function to the http.RoundTripper interface.
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip calls f(req).
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware wraps a RoundTripper to add behavior around every request sent by a client.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// chainMiddlewares wraps 'transport' with 'middlewares', the first middleware being the outermost.
+func chainMiddlewares(transport http.RoundTripper, middlewares []Middleware) http.RoundTripper {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		transport = middlewares[i](transport)
+	}
+	return transport
+}
+
+// IsIdempotent reports whether 'req' can be sent more than once, one after the other, e.g. by a retry or a failover.
+// That holds for the idempotent methods of RFC 9110 and for requests with an Idempotency-Key header.
+// A request body must be replayable through GetBody.
+func IsIdempotent(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get(HeaderIdempotencyKey) != "" || req.Header.Get("X-"+HeaderIdempotencyKey) != ""
+}
+
+// credentialHeaderKey is the context key of the API key header set with WithAuthAPIKey.
+type credentialHeaderKey struct{}
+
+// withCredentialHeader records the API key header of 'auth' in 'ctx', so middlewares can find it.
+func withCredentialHeader(ctx context.Context, authType AuthType, auth AuthCredentials) context.Context {
+	if authType != AuthTypeAPIKey || auth.keyLocation == APIKeyInQuery {
+		return ctx
+	}
+	return context.WithValue(ctx, credentialHeaderKey{}, http.CanonicalHeaderKey(auth.keyName))
+}
+
+// CredentialHeaders returns the names of the headers identifying the caller of 'req': Authorization,
+// Proxy-Authorization and Cookie, plus the API key header of WithAuthAPIKey when DoRequest sent 'req'.
+// Middlewares sharing responses between requests use them to keep callers apart.
+func CredentialHeaders(req *http.Request) []string {
+	result := []string{HeaderAuthorization, HeaderProxyAuthorization, HeaderCookie}
+	if name, ok := req.Context().Value(credentialHeaderKey{}).(string); ok {
+		result = append(result, name)
+	}
+	return result
+}
@@ -0,0 +1,109 @@
+package http
+
+import (
+	"github.com/guhungry/gohungry/http/httptest"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestWithMiddlewareOrder(t *testing.T) {
+	var calls []string
+	record := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				calls = append(calls, name)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+	respond := func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			calls = append(calls, "respond")
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+		})
+	}
+
+	client, err := NewClient(WithMiddleware(record("first"), record("second")), WithMiddleware(respond))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	res, err := client.Get("http://example.com")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	res.Body.Close()
+
+	expected := "first,second,respond"
+	if strings.Join(calls, ",") != expected {
+		t.Errorf("Expected calls %s, got %s", expected, strings.Join(calls, ","))
+	}
+}
+
+func TestIsIdempotent(t *testing.T) {
+	withKey := func(req *http.Request) *http.Request {
+		req.Header.Set(HeaderIdempotencyKey, "key")
+		return req
+	}
+	unreplayable := func(req *http.Request) *http.Request {
+		req.GetBody = nil
+		return req
+	}
+	newRequest := func(method string, body io.Reader) *http.Request {
+		req, _ := http.NewRequest(method, "http://example.com", body)
+		return req
+	}
+	tests := []struct {
+		name     string
+		req      *http.Request
+		expected bool
+	}{
+		{name: "GET", req: newRequest(MethodGet, nil), expected: true},
+		{name: "PUT With Body", req: newRequest(http.MethodPut, strings.NewReader("body")), expected: true},
+		{name: "POST", req: newRequest(MethodPost, strings.NewReader("body")), expected: false},
+		{name: "POST With Idempotency Key", req: withKey(newRequest(MethodPost, strings.NewReader("body"))), expected: true},
+		{name: "Body Not Replayable", req: unreplayable(newRequest(http.MethodPut, strings.NewReader("body"))), expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := IsIdempotent(tt.req); result != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestCredentialHeaders(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []RequestInfoOption[map[string]string]
+		expected string
+	}{
+		{name: "No API Key", expected: "Authorization, Proxy-Authorization, Cookie"},
+		{name: "API Key Header", options: []RequestInfoOption[map[string]string]{WithAuthAPIKey[map[string]string](APIKeyInHeader, "api-key", "secret")}, expected: "Authorization, Proxy-Authorization, Cookie, Api-Key"},
+		{name: "API Key Query", options: []RequestInfoOption[map[string]string]{WithAuthAPIKey[map[string]string](APIKeyInQuery, "api_key", "secret")}, expected: "Authorization, Proxy-Authorization, Cookie"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var headers []string
+			SetHTTPClient(&httptest.MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					headers = CredentialHeaders(req)
+					return httptest.MockHTTPClientSuccess(http.StatusOK, `{}`).DoFunc(req)
+				},
+			})
+			defer ResetHTTPClient()
+
+			requestInfo := NewRequestInfo(MethodGet, "https://example.com", nil, httptest.DummyRequestBodySerializer, httptest.DummyResponseBodyParser[map[string]string], tt.options...)
+			if _, err := DoRequest(requestInfo); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if actual := strings.Join(headers, ", "); actual != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, actual)
+			}
+		})
+	}
+}
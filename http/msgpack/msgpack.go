@@ -0,0 +1,48 @@
+// Package msgpack provides utilities for making HTTP requests with MessagePack payloads
+// and parsing MessagePack responses. It includes functions for performing GET and POST
+// requests with MessagePack content, along with customizable options for request configuration.
+package msgpack
+
+import (
+	"github.com/guhungry/gohungry/http"
+	"io"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// contentType specifies the MIME type for MessagePack content.
+const contentType = "application/msgpack"
+
+// Get performs an HTTP GET, decodes MessagePack response into 'Response'.
+// 'url' is the request target, 'options' customize the request.
+func Get[Response any](url string, options ...http.RequestInfoOption[Response]) (*Response, error) {
+	return requestMessagePack[Response](http.MethodGet, url, nil, options...)
+}
+
+// Post performs an HTTP POST with MessagePack body, decodes response into 'Response'.
+// 'url' is the request target, 'body' is the payload or an http.StreamBody, 'options' customize the request.
+func Post[Response any](url string, body any, options ...http.RequestInfoOption[Response]) (*Response, error) {
+	return requestMessagePack[Response](http.MethodPost, url, body, options...)
+}
+
+// requestMessagePack sends an HTTP request and decodes MessagePack response into 'Response'.
+// 'method' is the HTTP method, 'url' is the request target, 'body' is the payload for POST,
+// 'options' customize the request.
+func requestMessagePack[Response any](method string, url string, body any, options ...http.RequestInfoOption[Response]) (*Response, error) {
+	options = append(options,
+		http.WithAccept[Response](contentType),
+		http.WithContentType[Response](contentType),
+	)
+	request := http.NewRequestInfo(method, url, body, msgpack.Marshal, toResponseObject[Response], options...)
+	return http.DoRequest(request)
+}
+
+// toResponseObject decodes MessagePack into 'Response'.
+func toResponseObject[Response any](reader io.ReadCloser) (*Response, error) {
+	var result Response
+	decoder := msgpack.NewDecoder(reader)
+	if err := decoder.Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,91 @@
+package msgpack
+
+import (
+	"github.com/guhungry/gohungry/http/httptest"
+	"io"
+	"net/http"
+	"testing"
+
+	gohungry "github.com/guhungry/gohungry/http"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Mock response data structure
+type MockResponse struct {
+	Message string `msgpack:"message"`
+}
+
+// encode serializes 'value' for mocked responses.
+func encode(t *testing.T, value any) string {
+	t.Helper()
+	data, err := msgpack.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestGet(t *testing.T) {
+	mockClient := httptest.MockHTTPClientSuccess(200, encode(t, MockResponse{Message: "success"}))
+	gohungry.SetHTTPClient(mockClient)
+	defer gohungry.ResetHTTPClient()
+
+	response, err := Get[MockResponse]("http://example.com")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if response.Message != "success" {
+		t.Errorf("Expected message to be 'success', got %s", response.Message)
+	}
+}
+
+func TestPost(t *testing.T) {
+	var contentType, received string
+	gohungry.SetHTTPClient(&httptest.MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			data, _ := io.ReadAll(req.Body)
+			contentType, received = req.Header.Get(gohungry.HeaderContentType), string(data)
+			return httptest.MockHTTPClientSuccess(201, encode(t, MockResponse{Message: "created"})).Do(req)
+		},
+	})
+	defer gohungry.ResetHTTPClient()
+
+	requestBody := MockResponse{Message: "value"}
+	response, err := Post[MockResponse]("http://example.com", requestBody)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if response.Message != "created" {
+		t.Errorf("Expected message to be 'created', got %s", response.Message)
+	}
+	if contentType != "application/msgpack" {
+		t.Errorf("Expected Content-Type application/msgpack, got %s", contentType)
+	}
+	if received != encode(t, requestBody) {
+		t.Errorf("Expected encoded request body, got %q", received)
+	}
+}
+
+func TestGetWithError(t *testing.T) {
+	mockClient := httptest.MockHTTPClientError("network error")
+	gohungry.SetHTTPClient(mockClient)
+	defer gohungry.ResetHTTPClient()
+
+	_, err := Get[MockResponse]("http://example.com")
+	if err == nil || err.Error() != "network error" {
+		t.Fatalf("Expected network error, got %v", err)
+	}
+}
+
+func TestGetWithInvalidBody(t *testing.T) {
+	mockClient := httptest.MockHTTPClientSuccess(200, "\xc1")
+	gohungry.SetHTTPClient(mockClient)
+	defer gohungry.ResetHTTPClient()
+
+	_, err := Get[MockResponse]("http://example.com")
+	if err == nil {
+		t.Fatalf("Expected decoding error, got no error")
+	}
+}
@@ -0,0 +1,216 @@
+// Package otel instruments DoRequest with OpenTelemetry. A Hook starts a client span per request,
+// following the HTTP semantic conventions, with child spans for the serialize and decode stages.
+// It propagates the W3C traceparent and tracestate headers, and records the request duration and
+// the request and response body sizes. Register it with gohungry's SetHooks or WithHook.
+package otel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	gohungry "github.com/guhungry/gohungry/http"
+	global "go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope of the tracer and meter.
+const ScopeName = "github.com/guhungry/gohungry/http"
+
+// durationBuckets are the histogram boundaries, in seconds, advised by the semantic conventions.
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.075, 0.1, 0.25, 0.5, 0.75, 1, 2.5, 5, 7.5, 10}
+
+// Hook traces and measures the requests of DoRequest.
+type Hook struct {
+	tracer       trace.Tracer
+	propagator   propagation.TextMapPropagator
+	duration     metric.Float64Histogram
+	requestSize  metric.Int64Histogram
+	responseSize metric.Int64Histogram
+}
+
+// config holds the settings collected by Option.
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	propagator     propagation.TextMapPropagator
+}
+
+// Option modifies a Hook.
+type Option func(c *config)
+
+// WithTracerProvider creates spans with 'provider' instead of the global tracer provider.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = provider
+	}
+}
+
+// WithMeterProvider records metrics with 'provider' instead of the global meter provider.
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(c *config) {
+		c.meterProvider = provider
+	}
+}
+
+// WithPropagator injects the span context with 'propagator' instead of W3C Trace Context.
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(c *config) {
+		c.propagator = propagator
+	}
+}
+
+// New creates a Hook configured with 'options'.
+func New(options ...Option) (*Hook, error) {
+	c := &config{
+		tracerProvider: global.GetTracerProvider(),
+		meterProvider:  global.GetMeterProvider(),
+		propagator:     propagation.TraceContext{},
+	}
+	for _, option := range options {
+		option(c)
+	}
+
+	meter := c.meterProvider.Meter(ScopeName)
+	duration, err := meter.Float64Histogram("http.client.request.duration",
+		metric.WithUnit("s"), metric.WithDescription("Duration of HTTP client requests."), metric.WithExplicitBucketBoundaries(durationBuckets...))
+	if err != nil {
+		return nil, err
+	}
+	requestSize, err := meter.Int64Histogram("http.client.request.body.size",
+		metric.WithUnit("By"), metric.WithDescription("Size of HTTP client request bodies."))
+	if err != nil {
+		return nil, err
+	}
+	responseSize, err := meter.Int64Histogram("http.client.response.body.size",
+		metric.WithUnit("By"), metric.WithDescription("Size of HTTP client response bodies."))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Hook{
+		tracer:       c.tracerProvider.Tracer(ScopeName),
+		propagator:   c.propagator,
+		duration:     duration,
+		requestSize:  requestSize,
+		responseSize: responseSize,
+	}, nil
+}
+
+// RequestStart starts the client span of the request.
+func (h *Hook) RequestStart(ctx context.Context, event *gohungry.RequestEvent) context.Context {
+	attributes := append(serverAttributes(event), semconv.URLFull(event.URL))
+	ctx, _ = h.tracer.Start(ctx, spanName(event), trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(event.Start), trace.WithAttributes(attributes...))
+	return ctx
+}
+
+// RequestSend injects the span context into the request headers.
+func (h *Hook) RequestSend(ctx context.Context, req *http.Request) {
+	h.propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+}
+
+// StageEnd records a child span for the stage.
+func (h *Hook) StageEnd(ctx context.Context, stage gohungry.Stage, start time.Time, err error) {
+	_, span := h.tracer.Start(ctx, "gohungry."+string(stage), trace.WithTimestamp(start))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// RequestEnd ends the client span and records the metrics of the request.
+func (h *Hook) RequestEnd(ctx context.Context, event *gohungry.RequestEvent) {
+	attributes := serverAttributes(event)
+	if event.StatusCode > 0 {
+		attributes = append(attributes, semconv.HTTPResponseStatusCode(event.StatusCode))
+	}
+	errorType := errorType(event)
+	if errorType != "" {
+		attributes = append(attributes, semconv.ErrorTypeKey.String(errorType))
+	}
+
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attributes...)
+	if event.RequestSize >= 0 {
+		span.SetAttributes(semconv.HTTPRequestBodySize(int(event.RequestSize)))
+	}
+	span.SetAttributes(semconv.HTTPResponseBodySize(int(event.ResponseSize)))
+	if event.Err != nil {
+		span.RecordError(event.Err)
+	}
+	if errorType != "" {
+		span.SetStatus(codes.Error, errorType)
+	}
+	span.End(trace.WithTimestamp(event.Start.Add(event.Duration)))
+
+	measurement := metric.WithAttributes(attributes...)
+	h.duration.Record(ctx, event.Duration.Seconds(), measurement)
+	if event.RequestSize >= 0 {
+		h.requestSize.Record(ctx, event.RequestSize, measurement)
+	}
+	h.responseSize.Record(ctx, event.ResponseSize, measurement)
+}
+
+// serverAttributes returns the method, route and server attributes shared by spans and metrics.
+func serverAttributes(event *gohungry.RequestEvent) []attribute.KeyValue {
+	result := []attribute.KeyValue{semconv.HTTPRequestMethodKey.String(gohungry.TelemetryMethod(event.Method))}
+	if event.Route != "" {
+		result = append(result, semconv.URLTemplate(event.Route))
+	}
+	parsed, err := url.Parse(event.URL)
+	if err != nil {
+		return result
+	}
+	result = append(result, semconv.ServerAddress(parsed.Hostname()))
+	if port := serverPort(parsed); port > 0 {
+		result = append(result, semconv.ServerPort(port))
+	}
+	return result
+}
+
+// spanName returns the name of the client span: the method, followed by the route template when set.
+func spanName(event *gohungry.RequestEvent) string {
+	method := gohungry.TelemetryMethod(event.Method)
+	if method == gohungry.MethodOther {
+		method = "HTTP"
+	}
+	if event.Route == "" {
+		return method
+	}
+	return method + " " + event.Route
+}
+
+// serverPort returns the port of 'u', or the default port of its scheme.
+func serverPort(u *url.URL) int {
+	if port, err := strconv.Atoi(u.Port()); err == nil {
+		return port
+	}
+	switch u.Scheme {
+	case "http":
+		return 80
+	case "https":
+		return 443
+	}
+	return 0
+}
+
+// errorType returns the error.type of the request: the error type, or the status code for 4xx and 5xx.
+func errorType(event *gohungry.RequestEvent) string {
+	if event.Err != nil {
+		return fmt.Sprintf("%T", event.Err)
+	}
+	if event.StatusCode >= 400 {
+		return strconv.Itoa(event.StatusCode)
+	}
+	return ""
+}
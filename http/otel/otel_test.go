@@ -0,0 +1,168 @@
+package otel
+
+import (
+	"context"
+	"net/http"
+	nethttptest "net/http/httptest"
+	"strings"
+	"testing"
+
+	gohungry "github.com/guhungry/gohungry/http"
+	"github.com/guhungry/gohungry/http/httptest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newTestHook creates a Hook recording to an in-memory span recorder and metric reader.
+func newTestHook(t *testing.T) (*Hook, *tracetest.SpanRecorder, *sdkmetric.ManualReader) {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	hook, err := New(
+		WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return hook, recorder, reader
+}
+
+// spanAttribute returns the value of 'key' on 'span'.
+func spanAttribute(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestHook(t *testing.T) {
+	var traceparent string
+	server := nethttptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		_, _ = w.Write([]byte(`{"message":"success"}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int64
+		expectedError  string
+		expectedCode   codes.Code
+	}{
+		{name: "Success", path: "/ok", expectedStatus: http.StatusOK, expectedError: "", expectedCode: codes.Unset},
+		{name: "Not Found", path: "/missing", expectedStatus: http.StatusNotFound, expectedError: "404", expectedCode: codes.Error},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hook, recorder, reader := newTestHook(t)
+			requestInfo := gohungry.NewRequestInfo(gohungry.MethodPost, server.URL+tt.path, map[string]string{}, httptest.DummyRequestBodySerializer,
+				httptest.DummyResponseBodyParser[map[string]string], gohungry.WithHook[map[string]string](hook))
+			_, _ = gohungry.DoRequest(requestInfo)
+
+			spans := recorder.Ended()
+			if len(spans) != 3 {
+				t.Fatalf("Expected serialize, decode and client spans, got %d spans", len(spans))
+			}
+			client := spans[2]
+			if client.Name() != "POST" || client.SpanKind() != trace.SpanKindClient {
+				t.Errorf("Expected POST client span, got %s %v", client.Name(), client.SpanKind())
+			}
+			for _, stage := range spans[:2] {
+				if stage.Parent().SpanID() != client.SpanContext().SpanID() {
+					t.Errorf("Expected %s to be a child of the client span", stage.Name())
+				}
+			}
+			if spans[0].Name() != "gohungry.serialize" || spans[1].Name() != "gohungry.decode" {
+				t.Errorf("Expected stage spans, got %s and %s", spans[0].Name(), spans[1].Name())
+			}
+			if !strings.Contains(traceparent, client.SpanContext().TraceID().String()) || !strings.Contains(traceparent, client.SpanContext().SpanID().String()) {
+				t.Errorf("Expected traceparent of the client span, got %s", traceparent)
+			}
+			if status := spanAttribute(client, "http.response.status_code").AsInt64(); status != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, status)
+			}
+			if errorType := spanAttribute(client, "error.type").AsString(); errorType != tt.expectedError {
+				t.Errorf("Expected error.type %q, got %q", tt.expectedError, errorType)
+			}
+			if client.Status().Code != tt.expectedCode {
+				t.Errorf("Expected span status %v, got %v", tt.expectedCode, client.Status().Code)
+			}
+			if url := spanAttribute(client, "url.full").AsString(); url != server.URL+tt.path {
+				t.Errorf("Expected url.full %s, got %s", server.URL+tt.path, url)
+			}
+
+			var metrics metricdata.ResourceMetrics
+			if err := reader.Collect(context.Background(), &metrics); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			counts := map[string]uint64{}
+			for _, m := range metrics.ScopeMetrics[0].Metrics {
+				switch data := m.Data.(type) {
+				case metricdata.Histogram[float64]:
+					counts[m.Name] = data.DataPoints[0].Count
+				case metricdata.Histogram[int64]:
+					counts[m.Name] = data.DataPoints[0].Count
+					if m.Name == "http.client.request.body.size" && data.DataPoints[0].Sum != int64(len("dummy body")) {
+						t.Errorf("Expected request size 10, got %d", data.DataPoints[0].Sum)
+					}
+				}
+			}
+			for _, name := range []string{"http.client.request.duration", "http.client.request.body.size", "http.client.response.body.size"} {
+				if counts[name] != 1 {
+					t.Errorf("Expected one %s measurement, got %d", name, counts[name])
+				}
+			}
+		})
+	}
+}
+
+func TestHookRequestError(t *testing.T) {
+	hook, recorder, _ := newTestHook(t)
+	requestInfo := gohungry.NewRequestInfo(gohungry.MethodGet, "http://127.0.0.1:1", nil, httptest.DummyRequestBodySerializer,
+		httptest.DummyResponseBodyParser[map[string]string], gohungry.WithHook[map[string]string](hook))
+	if _, err := gohungry.DoRequest(requestInfo); err == nil {
+		t.Fatal("Expected connection error, got nil")
+	}
+
+	spans := recorder.Ended()
+	client := spans[len(spans)-1]
+	if client.Status().Code != codes.Error || spanAttribute(client, "error.type").AsString() != "*url.Error" {
+		t.Errorf("Expected *url.Error span error, got %v %s", client.Status(), spanAttribute(client, "error.type").AsString())
+	}
+	if port := spanAttribute(client, "server.port").AsInt64(); port != 1 {
+		t.Errorf("Expected server.port 1, got %d", port)
+	}
+	if len(client.Events()) != 1 || client.Events()[0].Name != "exception" {
+		t.Errorf("Expected the error to be recorded, got %v", client.Events())
+	}
+}
+
+func TestSpanName(t *testing.T) {
+	tests := []struct {
+		event    gohungry.RequestEvent
+		expected string
+	}{
+		{event: gohungry.RequestEvent{Method: http.MethodGet}, expected: "GET"},
+		{event: gohungry.RequestEvent{Method: http.MethodGet, Route: "/users/{id}"}, expected: "GET /users/{id}"},
+		{event: gohungry.RequestEvent{Method: "PURGE", Route: "/cache"}, expected: "HTTP /cache"},
+	}
+
+	for _, tt := range tests {
+		if actual := spanName(&tt.event); actual != tt.expected {
+			t.Errorf("Expected %s, got %s", tt.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// Package paginate iterates over the items of paginated list endpoints. A Strategy finds the
+// next page from the RFC 8288 Link header, a cursor in the body, or page and offset query parameters.
+package paginate
+
+import (
+	"context"
+	"github.com/guhungry/gohungry/http"
+)
+
+// FetchFunc requests one page, e.g. json.Get[Page].
+type FetchFunc[Page any] func(url string, options ...http.RequestInfoOption[Page]) (*Page, error)
+
+// Strategy returns the URL of the page after 'page', which was fetched from 'current' and held 'count' items.
+// An empty URL ends the iteration.
+type Strategy[Page any] func(current string, page *Page, result *http.Result, count int) (string, error)
+
+// config holds the settings collected by Option.
+type config[Page any] struct {
+	maxPages       int
+	maxItems       int
+	requestOptions []http.RequestInfoOption[Page]
+}
+
+// Option modifies an Iterator.
+type Option[Page any] func(c *config[Page])
+
+// WithMaxPages stops the iteration after 'n' pages.
+func WithMaxPages[Page any](n int) Option[Page] {
+	return func(c *config[Page]) {
+		c.maxPages = n
+	}
+}
+
+// WithMaxItems stops the iteration after 'n' items.
+func WithMaxItems[Page any](n int) Option[Page] {
+	return func(c *config[Page]) {
+		c.maxItems = n
+	}
+}
+
+// WithRequestOptions customizes the request of every page, e.g. with http.WithAuthBearer.
+func WithRequestOptions[Page any](options ...http.RequestInfoOption[Page]) Option[Page] {
+	return func(c *config[Page]) {
+		c.requestOptions = append(c.requestOptions, options...)
+	}
+}
+
+// Iterator walks through the items of every page. Call Next before each Item, then check Err:
+//
+//	users := func(page *UserPage) []User { return page.Users }
+//	it := paginate.New(ctx, json.Get[UserPage], url, users, paginate.LinkHeader[UserPage]())
+//	for it.Next() {
+//		user := it.Item()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type Iterator[Page, Item any] struct {
+	ctx      context.Context
+	fetch    FetchFunc[Page]
+	items    func(page *Page) []Item
+	strategy Strategy[Page]
+	config   *config[Page]
+	url      string // URL of the next page, empty after the last page
+	pending  []Item // Items of the current page not returned yet
+	item     Item
+	pages    int
+	count    int
+	err      error
+}
+
+// New creates an Iterator starting at 'url'. It fetches pages with 'fetch', takes their items
+// with 'items', and finds the following pages with 'strategy'.
+func New[Page, Item any](ctx context.Context, fetch FetchFunc[Page], url string, items func(page *Page) []Item, strategy Strategy[Page], options ...Option[Page]) *Iterator[Page, Item] {
+	c := &config[Page]{}
+	for _, option := range options {
+		option(c)
+	}
+	return &Iterator[Page, Item]{ctx: ctx, fetch: fetch, items: items, strategy: strategy, config: c, url: url}
+}
+
+// Next advances to the next item, fetching the next page when needed. It returns false
+// after the last item, when a limit is reached, on error, or once the context is done.
+func (it *Iterator[Page, Item]) Next() bool {
+	if it.err != nil || (it.config.maxItems > 0 && it.count >= it.config.maxItems) {
+		return false
+	}
+	if it.err = it.ctx.Err(); it.err != nil {
+		return false
+	}
+	for len(it.pending) == 0 {
+		if it.url == "" || (it.config.maxPages > 0 && it.pages >= it.config.maxPages) {
+			return false
+		}
+		if it.err = it.fetchPage(); it.err != nil {
+			return false
+		}
+	}
+
+	it.item, it.pending = it.pending[0], it.pending[1:]
+	it.count++
+	return true
+}
+
+// fetchPage fetches the page at it.url and finds the URL of the following one.
+func (it *Iterator[Page, Item]) fetchPage() error {
+	var result http.Result
+	options := append([]http.RequestInfoOption[Page]{http.WithContext[Page](it.ctx), http.WithResult[Page](&result)}, it.config.requestOptions...)
+	page, err := it.fetch(it.url, options...)
+	if err != nil {
+		return err
+	}
+	it.pages++
+	it.pending = it.items(page)
+
+	next, err := it.strategy(it.url, page, &result, len(it.pending))
+	if err != nil {
+		return err
+	}
+	if next == it.url {
+		// A server repeating the same page would never end the iteration
+		next = ""
+	}
+	it.url = next
+	return nil
+}
+
+// Item returns the current item.
+func (it *Iterator[Page, Item]) Item() Item {
+	return it.item
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *Iterator[Page, Item]) Err() error {
+	return it.err
+}
+
+// All returns the remaining items of every page.
+func (it *Iterator[Page, Item]) All() ([]Item, error) {
+	var result []Item
+	for it.Next() {
+		result = append(result, it.Item())
+	}
+	return result, it.Err()
+}
@@ -0,0 +1,214 @@
+package paginate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/guhungry/gohungry/http/httptest"
+	"github.com/guhungry/gohungry/http/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	gohungry "github.com/guhungry/gohungry/http"
+)
+
+type userPage struct {
+	Users []string `json:"users"`
+	Next  string   `json:"next"`
+}
+
+// users returns the items of a page.
+func users(page *userPage) []string {
+	return page.Users
+}
+
+// mockPages serves the pages returned by 'serve' for each request and records the requested URLs.
+func mockPages(requested *[]string, serve func(req *http.Request) (string, http.Header)) *httptest.MockHTTPClient {
+	return &httptest.MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			*requested = append(*requested, req.URL.String())
+			body, header := serve(req)
+			if header == nil {
+				header = http.Header{}
+			}
+			return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(strings.NewReader(body))}, nil
+		},
+	}
+}
+
+func TestLinkHeader(t *testing.T) {
+	var requested []string
+	gohungry.SetHTTPClient(mockPages(&requested, func(req *http.Request) (string, http.Header) {
+		switch req.URL.Query().Get("page") {
+		case "":
+			return `{"users":["a","b"]}`, http.Header{HeaderLink: {`<https://example.com/users?page=1>; rel="first", </users?page=2>; rel="next last"`}}
+		case "2":
+			return `{"users":["c"]}`, http.Header{HeaderLink: {`<https://example.com/users?page=1>; rel="first"`}}
+		}
+		return `{"users":[]}`, nil
+	}))
+	defer gohungry.ResetHTTPClient()
+
+	// A result requested by the caller does not replace the one the strategy reads
+	var result gohungry.Result
+	items, err := New(context.Background(), json.Get[userPage], "https://example.com/users", users, LinkHeader[userPage](),
+		WithRequestOptions(gohungry.WithResult[userPage](&result))).All()
+	if err != nil || !reflect.DeepEqual(items, []string{"a", "b", "c"}) {
+		t.Fatalf("Expected [a b c], got %v, %v", items, err)
+	}
+	if result.StatusCode != http.StatusOK {
+		t.Errorf("Expected the caller's result to be filled, got %+v", result)
+	}
+	expected := []string{"https://example.com/users", "https://example.com/users?page=2"}
+	if !reflect.DeepEqual(requested, expected) {
+		t.Errorf("Expected requests %v, got %v", expected, requested)
+	}
+}
+
+func TestCursor(t *testing.T) {
+	var requested []string
+	gohungry.SetHTTPClient(mockPages(&requested, func(req *http.Request) (string, http.Header) {
+		if req.URL.Query().Get("cursor") == "" {
+			return `{"users":["a"],"next":"abc"}`, nil
+		}
+		return `{"users":["b"],"next":""}`, nil
+	}))
+	defer gohungry.ResetHTTPClient()
+
+	cursor := func(page *userPage) string { return page.Next }
+	items, err := New(context.Background(), json.Get[userPage], "https://example.com/users?limit=1", users, Cursor("cursor", cursor)).All()
+	if err != nil || !reflect.DeepEqual(items, []string{"a", "b"}) {
+		t.Fatalf("Expected [a b], got %v, %v", items, err)
+	}
+	if requested[1] != "https://example.com/users?cursor=abc&limit=1" {
+		t.Errorf("Expected cursor in query, got %s", requested[1])
+	}
+}
+
+// numberedPages serves 'total' items, 'size' per page, selected by the query parameter 'param'.
+func numberedPages(requested *[]string, param string, toStart func(value int) int, total, size int) *httptest.MockHTTPClient {
+	return mockPages(requested, func(req *http.Request) (string, http.Header) {
+		value, _ := strconv.Atoi(req.URL.Query().Get(param))
+		var items []string
+		for i := toStart(value); i < total && len(items) < size; i++ {
+			items = append(items, fmt.Sprintf(`"%d"`, i))
+		}
+		return `{"users":[` + strings.Join(items, ",") + `]}`, nil
+	})
+}
+
+func TestPageNumberAndOffset(t *testing.T) {
+	tests := []struct {
+		name             string
+		client           func(requested *[]string) *httptest.MockHTTPClient
+		strategy         Strategy[userPage]
+		expectedRequests int
+	}{
+		{
+			name: "Page Number",
+			client: func(r *[]string) *httptest.MockHTTPClient {
+				return numberedPages(r, "page", func(p int) int { return (p - 1) * 2 }, 5, 2)
+			},
+			strategy:         PageNumber[userPage]("page", 1),
+			expectedRequests: 4,
+		},
+		{
+			name: "Offset",
+			client: func(r *[]string) *httptest.MockHTTPClient {
+				return numberedPages(r, "offset", func(o int) int { return o }, 5, 2)
+			},
+			strategy:         Offset[userPage]("offset"),
+			expectedRequests: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requested []string
+			gohungry.SetHTTPClient(tt.client(&requested))
+			defer gohungry.ResetHTTPClient()
+
+			items, err := New(context.Background(), json.Get[userPage], "https://example.com/users?page=1", users, tt.strategy).All()
+			if err != nil || !reflect.DeepEqual(items, []string{"0", "1", "2", "3", "4"}) {
+				t.Fatalf("Expected 5 items, got %v, %v", items, err)
+			}
+			if len(requested) != tt.expectedRequests {
+				t.Errorf("Expected %d requests, got %v", tt.expectedRequests, requested)
+			}
+		})
+	}
+}
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		name     string
+		option   Option[userPage]
+		expected []string
+	}{
+		{name: "Max Pages", option: WithMaxPages[userPage](2), expected: []string{"0", "1", "2", "3"}},
+		{name: "Max Items", option: WithMaxItems[userPage](3), expected: []string{"0", "1", "2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requested []string
+			gohungry.SetHTTPClient(numberedPages(&requested, "offset", func(o int) int { return o }, 100, 2))
+			defer gohungry.ResetHTTPClient()
+
+			items, err := New(context.Background(), json.Get[userPage], "https://example.com/users", users, Offset[userPage]("offset"), tt.option).All()
+			if err != nil || !reflect.DeepEqual(items, tt.expected) {
+				t.Errorf("Expected %v, got %v, %v", tt.expected, items, err)
+			}
+			if len(requested) != 2 {
+				t.Errorf("Expected 2 requests, got %v", requested)
+			}
+		})
+	}
+}
+
+func TestContextCancellation(t *testing.T) {
+	var requested []string
+	gohungry.SetHTTPClient(numberedPages(&requested, "offset", func(o int) int { return o }, 100, 2))
+	defer gohungry.ResetHTTPClient()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	it := New(ctx, json.Get[userPage], "https://example.com/users", users, Offset[userPage]("offset"))
+	if !it.Next() || it.Item() != "0" {
+		t.Fatalf("Expected first item, got %v", it.Err())
+	}
+	cancel()
+
+	if it.Next() {
+		t.Errorf("Expected iteration to stop, got %s", it.Item())
+	}
+	if !errors.Is(it.Err(), context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", it.Err())
+	}
+}
+
+func TestNextLink(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []string
+		expected string
+	}{
+		{name: "Single", values: []string{`<https://example.com/2>; rel="next"`}, expected: "https://example.com/2"},
+		{name: "Unquoted", values: []string{`<https://example.com/2>; rel=next`}, expected: "https://example.com/2"},
+		{name: "Among Others", values: []string{`<https://example.com/1>; rel="prev", <https://example.com/3>; title="x"; REL="Next"`}, expected: "https://example.com/3"},
+		{name: "Comma In URL", values: []string{`<https://example.com/?a=1,2>; rel="next"`}, expected: "https://example.com/?a=1,2"},
+		{name: "Several Headers", values: []string{`<https://example.com/1>; rel="prev"`, `<https://example.com/3>; rel="next"`}, expected: "https://example.com/3"},
+		{name: "None", values: []string{`<https://example.com/1>; rel="prev"`}, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if next := nextLink(tt.values); next != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, next)
+			}
+		})
+	}
+}
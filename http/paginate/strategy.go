@@ -0,0 +1,136 @@
+package paginate
+
+import (
+	"github.com/guhungry/gohungry/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// HeaderLink is the RFC 8288 header listing links to related pages.
+const HeaderLink = "Link"
+
+// LinkHeader follows the link with rel="next" of the Link header, resolved against the current URL.
+func LinkHeader[Page any]() Strategy[Page] {
+	return func(current string, page *Page, result *http.Result, count int) (string, error) {
+		next := nextLink(result.Header.Values(HeaderLink))
+		if next == "" {
+			return "", nil
+		}
+		return resolve(current, next)
+	}
+}
+
+// Cursor sets the query parameter 'param' to the cursor returned by 'cursor' for each page.
+// An empty cursor ends the iteration.
+func Cursor[Page any](param string, cursor func(page *Page) string) Strategy[Page] {
+	return func(current string, page *Page, result *http.Result, count int) (string, error) {
+		value := cursor(page)
+		if value == "" {
+			return "", nil
+		}
+		return setQuery(current, param, value)
+	}
+}
+
+// PageNumber increments the query parameter 'param', starting from 'first' when the URL has none.
+// A page without items ends the iteration.
+func PageNumber[Page any](param string, first int) Strategy[Page] {
+	return func(current string, page *Page, result *http.Result, count int) (string, error) {
+		if count == 0 {
+			return "", nil
+		}
+		number, err := queryInt(current, param, first)
+		if err != nil {
+			return "", err
+		}
+		return setQuery(current, param, strconv.Itoa(number+1))
+	}
+}
+
+// Offset advances the query parameter 'param' by the number of items on each page, starting from 0.
+// A page without items ends the iteration.
+func Offset[Page any](param string) Strategy[Page] {
+	return func(current string, page *Page, result *http.Result, count int) (string, error) {
+		if count == 0 {
+			return "", nil
+		}
+		offset, err := queryInt(current, param, 0)
+		if err != nil {
+			return "", err
+		}
+		return setQuery(current, param, strconv.Itoa(offset+count))
+	}
+}
+
+// nextLink returns the target of the link with rel="next" in Link header 'values', or empty.
+func nextLink(values []string) string {
+	for _, value := range values {
+		for value != "" {
+			start := strings.IndexByte(value, '<')
+			end := strings.IndexByte(value, '>')
+			if start < 0 || end < start {
+				break
+			}
+			target := value[start+1 : end]
+
+			// Parameters run until the next link
+			params := value[end+1:]
+			value = ""
+			if next := strings.IndexByte(params, '<'); next >= 0 {
+				params, value = params[:next], params[next:]
+			}
+			for _, param := range strings.Split(params, ";") {
+				name, rel, ok := strings.Cut(strings.TrimSpace(param), "=")
+				if !ok || !strings.EqualFold(strings.TrimSpace(name), "rel") {
+					continue
+				}
+				// rel may hold several space separated relation types
+				for _, relation := range strings.Fields(strings.Trim(strings.TrimSpace(rel), `",`)) {
+					if strings.EqualFold(relation, "next") {
+						return target
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// resolve resolves 'reference' against 'base'.
+func resolve(base string, reference string) (string, error) {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	referenceURL, err := url.Parse(reference)
+	if err != nil {
+		return "", err
+	}
+	return baseURL.ResolveReference(referenceURL).String(), nil
+}
+
+// setQuery sets the query parameter 'param' of 'rawURL' to 'value'.
+func setQuery(rawURL string, param string, value string) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	query := parsed.Query()
+	query.Set(param, value)
+	parsed.RawQuery = query.Encode()
+	return parsed.String(), nil
+}
+
+// queryInt returns the integer query parameter 'param' of 'rawURL', or 'fallback' when it is missing.
+func queryInt(rawURL string, param string, fallback int) (int, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return 0, err
+	}
+	value := parsed.Query().Get(param)
+	if value == "" {
+		return fallback, nil
+	}
+	return strconv.Atoi(value)
+}
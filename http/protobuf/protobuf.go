@@ -0,0 +1,99 @@
+// Package protobuf provides utilities for making HTTP requests with Protocol Buffers payloads
+// and parsing Protocol Buffers responses. It includes functions for performing GET and POST
+// requests with protobuf content, and decodes google.rpc.Status error bodies into a *StatusError.
+package protobuf
+
+import (
+	"errors"
+	"fmt"
+	"github.com/guhungry/gohungry/http"
+	"io"
+
+	"google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// contentType specifies the MIME type for protobuf content.
+const contentType = "application/x-protobuf"
+
+// ErrNotMessage is returned when a request body is not a proto.Message.
+var ErrNotMessage = errors.New("gohungry: request body is not a proto.Message")
+
+// Message is satisfied by the pointer to a generated message type, e.g. *pb.User for pb.User.
+type Message[Response any] interface {
+	*Response
+	proto.Message
+}
+
+// Get performs an HTTP GET, decodes protobuf response into 'Response'.
+// 'url' is the request target, 'options' customize the request.
+func Get[Response any, PResponse Message[Response]](url string, options ...http.RequestInfoOption[Response]) (*Response, error) {
+	return requestProtobuf[Response, PResponse](http.MethodGet, url, nil, options...)
+}
+
+// Post performs an HTTP POST with protobuf body, decodes response into 'Response'.
+// 'url' is the request target, 'body' is the payload, 'options' customize the request.
+func Post[Response any, PResponse Message[Response]](url string, body proto.Message, options ...http.RequestInfoOption[Response]) (*Response, error) {
+	return requestProtobuf[Response, PResponse](http.MethodPost, url, body, options...)
+}
+
+// requestProtobuf sends an HTTP request and decodes protobuf response into 'Response'.
+// 'method' is the HTTP method, 'url' is the request target, 'body' is the payload for POST,
+// 'options' customize the request.
+func requestProtobuf[Response any, PResponse Message[Response]](method string, url string, body proto.Message, options ...http.RequestInfoOption[Response]) (*Response, error) {
+	options = append(options,
+		http.WithAccept[Response](contentType),
+		http.WithContentType[Response](contentType),
+		http.WithErrorParser[Response](toStatusError),
+	)
+	request := http.NewRequestInfo(method, url, body, marshal, toResponseObject[Response, PResponse], options...)
+	return http.DoRequest(request)
+}
+
+// marshal serializes a proto.Message body.
+func marshal(body any) ([]byte, error) {
+	message, ok := body.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrNotMessage, body)
+	}
+	return proto.Marshal(message)
+}
+
+// toResponseObject decodes protobuf into 'Response'.
+func toResponseObject[Response any, PResponse Message[Response]](reader io.ReadCloser) (*Response, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	var result Response
+	if err := proto.Unmarshal(data, PResponse(&result)); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// StatusError is returned for responses with a status code outside 2xx. It carries the
+// google.rpc.Status of the body, or a status without code and message when the body is not one.
+type StatusError struct {
+	StatusCode int            // HTTP status code
+	Status     *status.Status // Decoded google.rpc.Status
+}
+
+// Error formats the HTTP status code and the RPC status.
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("gohungry: HTTP status %d, rpc code %d: %s", e.StatusCode, e.Status.GetCode(), e.Status.GetMessage())
+}
+
+// toStatusError decodes a google.rpc.Status error body.
+func toStatusError(statusCode int, body io.Reader) error {
+	result := &StatusError{StatusCode: statusCode, Status: &status.Status{}}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	if err := proto.Unmarshal(data, result.Status); err != nil {
+		// A body that is not a google.rpc.Status, e.g. an HTML error page, still fails the request
+		result.Status = &status.Status{}
+	}
+	return result
+}
@@ -0,0 +1,100 @@
+package protobuf
+
+import (
+	"errors"
+	"github.com/guhungry/gohungry/http/httptest"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	gohungry "github.com/guhungry/gohungry/http"
+	"google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// encode serializes 'message' for mocked responses.
+func encode(t *testing.T, message proto.Message) string {
+	t.Helper()
+	data, err := proto.Marshal(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestGet(t *testing.T) {
+	mockClient := httptest.MockHTTPClientSuccess(200, encode(t, wrapperspb.String("success")))
+	gohungry.SetHTTPClient(mockClient)
+	defer gohungry.ResetHTTPClient()
+
+	response, err := Get[wrapperspb.StringValue]("http://example.com")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if response.GetValue() != "success" {
+		t.Errorf("Expected value to be 'success', got %s", response.GetValue())
+	}
+}
+
+func TestPost(t *testing.T) {
+	var contentType string
+	var received wrapperspb.StringValue
+	gohungry.SetHTTPClient(&httptest.MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			data, _ := io.ReadAll(req.Body)
+			contentType = req.Header.Get(gohungry.HeaderContentType)
+			if err := proto.Unmarshal(data, &received); err != nil {
+				t.Errorf("Expected protobuf request body, got %v", err)
+			}
+			return httptest.MockHTTPClientSuccess(201, encode(t, wrapperspb.Int64(42))).Do(req)
+		},
+	})
+	defer gohungry.ResetHTTPClient()
+
+	response, err := Post[wrapperspb.Int64Value]("http://example.com", wrapperspb.String("value"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if response.GetValue() != 42 {
+		t.Errorf("Expected value to be 42, got %d", response.GetValue())
+	}
+	if contentType != "application/x-protobuf" || received.GetValue() != "value" {
+		t.Errorf("Expected protobuf body 'value', got %s, %s", contentType, received.GetValue())
+	}
+}
+
+func TestGetWithStatusError(t *testing.T) {
+	tests := []struct {
+		name            string
+		body            string
+		expectedCode    int32
+		expectedMessage string
+	}{
+		{name: "RPC Status", body: encode(t, &status.Status{Code: 5, Message: "user not found"}), expectedCode: 5, expectedMessage: "user not found"},
+		{name: "Not A Status", body: "\xff<html>", expectedCode: 0, expectedMessage: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gohungry.SetHTTPClient(&httptest.MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					return &http.Response{StatusCode: http.StatusNotFound, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(tt.body))}, nil
+				},
+			})
+			defer gohungry.ResetHTTPClient()
+
+			_, err := Get[wrapperspb.StringValue]("http://example.com")
+			var statusErr *StatusError
+			if !errors.As(err, &statusErr) {
+				t.Fatalf("Expected *StatusError, got %v", err)
+			}
+			if statusErr.StatusCode != http.StatusNotFound || statusErr.Status.GetCode() != tt.expectedCode || statusErr.Status.GetMessage() != tt.expectedMessage {
+				t.Errorf("Expected 404 with code %d %q, got %v", tt.expectedCode, tt.expectedMessage, statusErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+// Package ratelimit provides client-side token bucket rate limiting for HTTP requests.
+// A Limiter shared by every client limits requests globally, a Limiter per client limits
+// that client, and a HostLimiter keeps a separate bucket for each host. Limits are attached
+// to a client with the Middleware methods and gohungry's WithMiddleware client option.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	gohungry "github.com/guhungry/gohungry/http"
+)
+
+// ErrInvalidLimit is returned when a rate or a burst cannot limit requests.
+var ErrInvalidLimit = errors.New("gohungry: invalid rate limit")
+
+// Limiter is a token bucket that allows 'rate' requests per second with bursts of up to 'burst' requests.
+type Limiter struct {
+	mu           sync.Mutex
+	rate         float64
+	burst        float64
+	tokens       float64
+	last         time.Time
+	blockedUntil time.Time
+}
+
+// New creates a Limiter allowing 'rate' requests per second and bursts of 'burst' requests.
+// The bucket starts full. It returns ErrInvalidLimit when 'rate' or 'burst' is not positive.
+func New(rate float64, burst int) (*Limiter, error) {
+	if err := checkLimit(rate, burst); err != nil {
+		return nil, err
+	}
+	return newLimiter(rate, burst), nil
+}
+
+// newLimiter creates a Limiter with a full bucket from a checked 'rate' and 'burst'.
+func newLimiter(rate float64, burst int) *Limiter {
+	return &Limiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until a request is allowed or 'ctx' is done.
+// It returns the context error when 'ctx' is done first.
+func (l *Limiter) Wait(ctx context.Context) error {
+	delay := l.reserve()
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.cancel()
+		return ctx.Err()
+	}
+}
+
+// checkLimit rejects a 'rate' that cannot limit requests, as a limiter that never refills,
+// or a negative delay from dividing by zero, would not, and a 'burst' that allows no request.
+func checkLimit(rate float64, burst int) error {
+	if !(rate > 0) {
+		return fmt.Errorf("%w: rate must be positive, got %v", ErrInvalidLimit, rate)
+	}
+	if burst < 1 {
+		return fmt.Errorf("%w: burst must be positive, got %d", ErrInvalidLimit, burst)
+	}
+	return nil
+}
+
+// reserve takes a token and returns how long to wait before using it.
+func (l *Limiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.refill(now)
+	l.tokens--
+
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(math.MaxInt64)
+		// Very low rates would overflow the delay
+		if wait := -l.tokens / l.rate * float64(time.Second); wait < float64(math.MaxInt64) {
+			delay = time.Duration(wait)
+		}
+	}
+	if blocked := l.blockedUntil.Sub(now); blocked > delay {
+		delay = blocked
+	}
+	return delay
+}
+
+// cancel returns a reserved token that was not used.
+func (l *Limiter) cancel() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill(time.Now())
+	l.tokens = math.Min(l.tokens+1, l.burst)
+}
+
+// isIdle reports whether the bucket is full and not blocked at 'now', so a new Limiter would behave the same.
+func (l *Limiter) isIdle(now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill(now)
+	return l.tokens >= l.burst && !now.Before(l.blockedUntil)
+}
+
+// refill adds the tokens earned since the last update.
+func (l *Limiter) refill(now time.Time) {
+	elapsed := now.Sub(l.last).Seconds()
+	l.last = now
+	if elapsed > 0 {
+		l.tokens = math.Min(l.tokens+elapsed*l.rate, l.burst)
+	}
+}
+
+// blockUntil holds every request until 't', e.g. when the server quota is exhausted.
+func (l *Limiter) blockUntil(t time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if t.After(l.blockedUntil) {
+		l.blockedUntil = t
+	}
+}
+
+// Middleware limits every request of a client with this Limiter.
+func (l *Limiter) Middleware(options ...Option) gohungry.Middleware {
+	return middleware(func(*http.Request) *Limiter { return l }, options)
+}
+
+// minSweepSize is the number of host limiters from which idle ones are evicted.
+const minSweepSize = 64
+
+// HostLimiter keeps a separate Limiter for each request host. Limiters of hosts that are idle,
+// with a full bucket, are evicted as new hosts are added, so the number of hosts is not bounded
+// by every host ever seen.
+type HostLimiter struct {
+	mu        sync.Mutex
+	rate      float64
+	burst     int
+	limiters  map[string]*Limiter
+	sweepSize int // Number of limiters from which idle ones are evicted
+}
+
+// NewHostLimiter creates a HostLimiter allowing 'rate' requests per second and bursts of 'burst' requests per host.
+// It returns ErrInvalidLimit when 'rate' or 'burst' is not positive.
+func NewHostLimiter(rate float64, burst int) (*HostLimiter, error) {
+	if err := checkLimit(rate, burst); err != nil {
+		return nil, err
+	}
+	return &HostLimiter{rate: rate, burst: burst, limiters: make(map[string]*Limiter), sweepSize: minSweepSize}, nil
+}
+
+// Limiter returns the Limiter for 'host', creating it on first use.
+func (h *HostLimiter) Limiter(host string) *Limiter {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	limiter, ok := h.limiters[host]
+	if !ok {
+		if len(h.limiters) >= h.sweepSize {
+			h.evictIdle()
+		}
+		limiter = newLimiter(h.rate, h.burst)
+		h.limiters[host] = limiter
+	}
+	return limiter
+}
+
+// evictIdle removes the idle limiters. The next sweep happens once the limiters doubled,
+// so sweeping costs a constant time per added host.
+func (h *HostLimiter) evictIdle() {
+	now := time.Now()
+	for host, limiter := range h.limiters {
+		if limiter.isIdle(now) {
+			delete(h.limiters, host)
+		}
+	}
+	h.sweepSize = max(2*len(h.limiters), minSweepSize)
+}
+
+// Middleware limits every request of a client with the Limiter of the request host.
+func (h *HostLimiter) Middleware(options ...Option) gohungry.Middleware {
+	return middleware(func(req *http.Request) *Limiter { return h.Limiter(req.URL.Host) }, options)
+}
+
+// config holds the settings collected by Option.
+type config struct {
+	adaptive bool
+}
+
+// Option modifies the rate limiting middleware.
+type Option func(c *config)
+
+// WithAdaptiveHeaders holds requests until the quota resets once a response reports
+// no remaining requests through the X-RateLimit-Remaining and X-RateLimit-Reset headers.
+func WithAdaptiveHeaders() Option {
+	return func(c *config) {
+		c.adaptive = true
+	}
+}
+
+// middleware waits for the Limiter selected by 'limiterFor' before sending each request.
+func middleware(limiterFor func(req *http.Request) *Limiter, options []Option) gohungry.Middleware {
+	config := &config{}
+	for _, option := range options {
+		option(config)
+	}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return gohungry.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			limiter := limiterFor(req)
+			if err := limiter.Wait(req.Context()); err != nil {
+				return nil, err
+			}
+
+			res, err := next.RoundTrip(req)
+			if err == nil && config.adaptive {
+				if reset, ok := quotaReset(res.Header, time.Now()); ok {
+					limiter.blockUntil(reset)
+				}
+			}
+			return res, err
+		})
+	}
+}
+
+// quotaReset returns when the quota resets if the headers report that no requests remain.
+// The reset header may hold a Unix timestamp or a number of seconds from 'now'.
+func quotaReset(header http.Header, now time.Time) (time.Time, bool) {
+	remaining, err := strconv.Atoi(header.Get(gohungry.HeaderRateLimitRemaining))
+	if err != nil || remaining > 0 {
+		return time.Time{}, false
+	}
+	reset, err := strconv.ParseInt(header.Get(gohungry.HeaderRateLimitReset), 10, 64)
+	if err != nil || reset < 0 {
+		return time.Time{}, false
+	}
+
+	// Values this large cannot be a delay, so they are Unix timestamps
+	if reset >= 1_000_000_000 {
+		return time.Unix(reset, 0), true
+	}
+	return now.Add(time.Duration(reset) * time.Second), true
+}
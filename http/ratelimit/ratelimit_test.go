@@ -0,0 +1,167 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	gohungry "github.com/guhungry/gohungry/http"
+)
+
+// okTransport returns an empty 200 response with 'header' for every request.
+func okTransport(header http.Header) http.RoundTripper {
+	return gohungry.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(strings.NewReader(""))}, nil
+	})
+}
+
+func TestLimiterBurstThenRate(t *testing.T) {
+	limiter, _ := New(50, 2)
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	// 2 requests from the burst, then 2 more at 50 per second
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("Expected requests to be delayed, took %v", elapsed)
+	}
+}
+
+func TestLimiterWaitContextCancelled(t *testing.T) {
+	limiter, _ := New(1, 1)
+	_ = limiter.Wait(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected deadline exceeded, got %v", err)
+	}
+
+	// The cancelled reservation is returned to the bucket
+	if limiter.tokens < -0.1 {
+		t.Errorf("Expected cancelled token to be returned, got %v tokens", limiter.tokens)
+	}
+}
+
+func TestNewInvalidLimit(t *testing.T) {
+	tests := []struct {
+		name string
+		new  func() error
+	}{
+		{name: "Zero Rate", new: func() error { _, err := New(0, 1); return err }},
+		{name: "Negative Rate", new: func() error { _, err := New(-1, 1); return err }},
+		{name: "Zero Burst", new: func() error { _, err := New(1, 0); return err }},
+		{name: "Host Limiter Zero Rate", new: func() error { _, err := NewHostLimiter(0, 1); return err }},
+		{name: "Host Limiter Negative Burst", new: func() error { _, err := NewHostLimiter(1, -1); return err }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.new(); !errors.Is(err, ErrInvalidLimit) {
+				t.Errorf("Expected ErrInvalidLimit, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLimiterLowRate(t *testing.T) {
+	limiter, _ := New(1e-12, 1)
+	_ = limiter.Wait(context.Background())
+
+	if delay := limiter.reserve(); delay <= 0 {
+		t.Errorf("Expected a positive delay, got %v", delay)
+	}
+}
+
+func TestHostLimiterSeparatesHosts(t *testing.T) {
+	limiters, _ := NewHostLimiter(1, 1)
+	transport := limiters.Middleware()(okTransport(nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	for _, url := range []string{"http://a.example.com", "http://b.example.com"} {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if _, err := transport.RoundTrip(req); err != nil {
+			t.Fatalf("Expected no error for %s, got %v", url, err)
+		}
+	}
+
+	// The second request to the same host has to wait longer than the deadline
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://a.example.com", nil)
+	if _, err := transport.RoundTrip(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestHostLimiterEvictsIdleHosts(t *testing.T) {
+	limiters, _ := NewHostLimiter(1, 1)
+	busy := limiters.Limiter("busy.example.com")
+	_ = busy.Wait(context.Background())
+	for i := range 2 * minSweepSize {
+		limiters.Limiter(strconv.Itoa(i) + ".example.com")
+	}
+
+	if count := len(limiters.limiters); count > minSweepSize+1 {
+		t.Errorf("Expected idle hosts to be evicted, got %d limiters", count)
+	}
+	if limiters.Limiter("busy.example.com") != busy {
+		t.Error("Expected the limiter of a busy host to be kept")
+	}
+}
+
+func TestMiddlewareAdaptiveHeaders(t *testing.T) {
+	header := http.Header{}
+	header.Set(gohungry.HeaderRateLimitRemaining, "0")
+	header.Set(gohungry.HeaderRateLimitReset, "60")
+	limiter, _ := New(1000, 10)
+	transport := limiter.Middleware(WithAdaptiveHeaders())(okTransport(header))
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	if _, err := transport.RoundTrip(req); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, "http://example.com", nil)
+	if _, err := transport.RoundTrip(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected request to wait for the quota reset, got %v", err)
+	}
+}
+
+func TestQuotaReset(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	tests := []struct {
+		name      string
+		remaining string
+		reset     string
+		expected  time.Time
+		ok        bool
+	}{
+		{name: "Delta Seconds", remaining: "0", reset: "30", expected: now.Add(30 * time.Second), ok: true},
+		{name: "Unix Timestamp", remaining: "0", reset: strconv.Itoa(1_700_000_100), expected: time.Unix(1_700_000_100, 0), ok: true},
+		{name: "Remaining Quota", remaining: "5", reset: "30", ok: false},
+		{name: "Missing Headers", ok: false},
+		{name: "Invalid Reset", remaining: "0", reset: "soon", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			header.Set(gohungry.HeaderRateLimitRemaining, tt.remaining)
+			header.Set(gohungry.HeaderRateLimitReset, tt.reset)
+			reset, ok := quotaReset(header, now)
+			if ok != tt.ok || !reset.Equal(tt.expected) {
+				t.Errorf("quotaReset() = %v, %v, expected %v, %v", reset, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
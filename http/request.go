@@ -2,46 +2,158 @@ package http
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"io"
 	"log"
 	"net/http"
+	"net/url"
+	"time"
 )
 
 // DoRequest executes an HTTP request and decodes the response into 'Request'.
 // 'data' contains request configurations and handlers.
 func DoRequest[Request any](data *RequestInfo[Request]) (*Request, error) {
+	event := &RequestEvent{Method: data.method, URL: eventURL(data.url, data.authCredentials), Route: data.route, Start: time.Now(), RequestSize: -1}
+	requestHooks := data.hooks
+	if data.timings {
+		requestHooks = append([]Hook{newTimingHook(data.results)}, requestHooks...)
+	}
+	ctx, hooks := startHooks(data.requestContext(), requestHooks, event)
+
+	response, err := doRequest(ctx, data, hooks, event)
+	event.Duration = time.Since(event.Start)
+	event.Err = err
+	hooks.end(event)
+	return response, err
+}
+
+// doRequest runs the stages of DoRequest under 'ctx', reporting them to 'hooks' and filling 'event'.
+func doRequest[Request any](ctx context.Context, data *RequestInfo[Request], hooks *hookRun, event *RequestEvent) (*Request, error) {
+	// Validate Request
+	if err := validateRequest(data.body); err != nil {
+		log.Println("validate request error:", err)
+		return nil, err
+	}
+
 	// Serialize Request Body
+	serializeStart := time.Now()
 	bodyReader, err := toBodyReader(data.body, data.bodySerializer)
+	hooks.stageEnd(StageSerialize, serializeStart, err)
 	if err != nil {
 		log.Println("parse request error:", err)
 		return nil, err
 	}
+	if buffered, ok := bodyReader.(*bytes.Reader); ok {
+		event.RequestSize = int64(buffered.Len())
+	}
+
+	// Validate Request Body, requests without one have nothing to check
+	if data.body != nil && len(data.requestValidators) > 0 {
+		if bodyReader, err = validateBody(bodyReader, data.requestValidators); err != nil {
+			log.Println("validate request error:", err)
+			return nil, err
+		}
+	}
+
+	// Compress Request Body
+	bodyReader, compressed, err := compressBody(bodyReader, data.compression, data.compressionMin)
+	if err != nil {
+		log.Println("compress request error:", err)
+		return nil, err
+	}
 
 	// Make Http Request
-	req, err := http.NewRequest(data.method, data.url, bodyReader)
+	ctx = withCredentialHeader(ctx, data.authType, data.authCredentials)
+	req, err := http.NewRequestWithContext(ctx, data.method, data.url, bodyReader)
 	if err != nil {
+		err = redactError(err, data.authCredentials)
 		log.Println("http request error:", err)
 		return nil, err
 	}
 	setAuth(req, data)
 	setHeaders(req, data.headers)
+	setAcceptEncoding(req)
+	if compressed {
+		req.Header.Set(HeaderContentEncoding, data.compression)
+	}
 
 	// Send Http Request
+	hooks.send(req)
 	res, err := Client.Do(req)
 	if err != nil {
+		err = redactError(err, data.authCredentials)
 		log.Println("client do error:", err)
 		return nil, err
 	}
 	defer res.Body.Close()
+	setResults(data.results, res)
+	event.StatusCode = res.StatusCode
+
+	// Limit and Decompress Response
+	res.Body = &countingReadCloser{ReadCloser: res.Body, count: &event.ResponseSize}
+	res.Body = limitResponseBody(res.Body, data.maxResponse, false)
+	body, err := decodeBody(res)
+	if err != nil {
+		log.Println("decode response error:", err)
+		return nil, err
+	}
+	defer body.Close()
+	if body != res.Body {
+		// The decompressed size is limited as well
+		body = limitResponseBody(body, data.maxResponse, true)
+	}
+
+	// Parse Error Response
+	if data.errorParser != nil && !isSuccess(res.StatusCode) {
+		if body, err = parseErrorResponse(res.StatusCode, body, data.errorParser); err != nil {
+			log.Println("error response:", err)
+			return nil, err
+		}
+	}
+
+	// Validate Response Body
+	if len(data.responseValidators) > 0 {
+		validated, err := validateBody(body, data.responseValidators)
+		if err != nil {
+			log.Println("validate response error:", err)
+			return nil, err
+		}
+		body = io.NopCloser(validated)
+	}
 
 	// Deserialize Response
-	response, err := data.responseParser(res.Body)
+	decodeStart := time.Now()
+	response, err := data.responseParser(body)
+	hooks.stageEnd(StageDecode, decodeStart, err)
 	if err != nil {
 		log.Println("parse response error:", err)
 		return nil, err
 	}
+
+	// Validate Response
+	if err := validateResponse(response, data.responseValidator); err != nil {
+		log.Println("validate response error:", err)
+		return nil, err
+	}
 	return response, nil
 }
 
+// DoRequestContext is DoRequest running under 'ctx' as well as the context set by WithContext,
+// so the request stops when either is done. 'data' is not modified.
+func DoRequestContext[Request any](ctx context.Context, data *RequestInfo[Request]) (*Request, error) {
+	if data.ctx != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		stop := context.AfterFunc(data.ctx, cancel)
+		defer stop()
+	}
+	info := *data
+	info.ctx = ctx
+	return DoRequest(&info)
+}
+
 // setHeaders applies provided headers to the HTTP request.
 func setHeaders(req *http.Request, headers Headers) {
 	for k, v := range headers {
@@ -57,17 +169,44 @@ func setAuth[Request any](req *http.Request, data *RequestInfo[Request]) {
 		req.SetBasicAuth(auth.username, auth.password)
 	case AuthTypeBearer:
 		req.Header.Add(HeaderAuthorization, string(AuthTypeBearer)+" "+auth.token)
+	case AuthTypeAPIKey:
+		setAPIKey(req, auth)
 	default:
 		// No authentication required
 	}
 }
 
+// setAPIKey places the API key in a header or merges it into the URL query.
+func setAPIKey(req *http.Request, auth AuthCredentials) {
+	switch auth.keyLocation {
+	case APIKeyInQuery:
+		query := req.URL.Query()
+		query.Set(auth.keyName, auth.key)
+		req.URL.RawQuery = query.Encode()
+	default:
+		req.Header.Set(auth.keyName, auth.key)
+	}
+}
+
+// redactError hides credentials from URLs carried by 'err', so it is safe to log.
+func redactError(err error, auth AuthCredentials) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = auth.redact(urlErr.URL)
+	}
+	return err
+}
+
 // toBodyReader creates a reader for serialized request body.
 // 'body' is the payload, 'serializer' converts it to a byte slice.
-func toBodyReader(body any, serializer RequestBodySerializer) (*bytes.Reader, error) {
+// A StreamBody is streamed as is, without serialization.
+func toBodyReader(body any, serializer RequestBodySerializer) (io.Reader, error) {
 	if body == nil {
 		return bytes.NewReader([]byte{}), nil
 	}
+	if stream, ok := body.(StreamBody); ok {
+		return stream.Reader, nil
+	}
 
 	requestBody, err := serializer(body)
 	if err != nil {
@@ -76,3 +215,16 @@ func toBodyReader(body any, serializer RequestBodySerializer) (*bytes.Reader, er
 
 	return bytes.NewReader(requestBody), nil
 }
+
+// countingReadCloser adds the number of bytes read to 'count'.
+type countingReadCloser struct {
+	io.ReadCloser
+	count *int64
+}
+
+// Read reads from the underlying reader and counts the bytes.
+func (c *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	*c.count += int64(n)
+	return n, err
+}
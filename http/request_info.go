@@ -1,10 +1,27 @@
 package http
 
-import "io"
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// redacted replaces secrets in logs and debug dumps.
+const redacted = "REDACTED"
 
 // RequestBodySerializer serializes a request body into a byte slice.
 type RequestBodySerializer func(body any) ([]byte, error)
 
+// StreamBody is a request body that is sent as is, read from 'Reader' while the request is sent,
+// instead of being serialized. It is not buffered, so its size is unknown to hooks and it cannot be
+// replayed, e.g. by retries or hedges. Any other body, readers included, goes through the serializer.
+// The codec packages accept it too, e.g. to send an already encoded JSON document with json.Post.
+type StreamBody struct {
+	Reader io.Reader
+}
+
 // ResponseBodyParser decodes a response body into a specified type 'Response'.
 type ResponseBodyParser[Response any] func(reader io.ReadCloser) (*Response, error)
 
@@ -12,26 +29,81 @@ type ResponseBodyParser[Response any] func(reader io.ReadCloser) (*Response, err
 // for the request body and deserialization for the response body.
 // Use NewRequestInfo to initialize this struct.
 type RequestInfo[Response any] struct {
-	method          string
-	url             string
-	body            any
-	bodySerializer  RequestBodySerializer
-	responseParser  ResponseBodyParser[Response]
-	authType        AuthType
-	authCredentials AuthCredentials
-	headers         Headers // HTTP Headers
+	method             string
+	url                string
+	body               any
+	bodySerializer     RequestBodySerializer
+	responseParser     ResponseBodyParser[Response]
+	authType           AuthType
+	authCredentials    AuthCredentials
+	headers            Headers // HTTP Headers
+	ctx                context.Context
+	results            []*Result
+	compression        string
+	compressionMin     int
+	maxResponse        int64
+	requestValidators  []BodyValidator
+	responseValidators []BodyValidator
+	responseValidator  func(response *Response) error
+	errorParser        ResponseErrorParser
+	hooks              []Hook
+	route              string
+	timings            bool
 }
 
 // AuthCredentials holds authentication credentials.
 type AuthCredentials struct {
-	username string
-	password string
-	token    string
+	username    string
+	password    string
+	token       string
+	keyLocation APIKeyLocation
+	keyName     string
+	key         string
+}
+
+// String returns the credentials with every secret redacted.
+func (c AuthCredentials) String() string {
+	return fmt.Sprintf("{username:%s password:%s token:%s keyLocation:%s keyName:%s key:%s}",
+		c.username, redactSecret(c.password), redactSecret(c.token), c.keyLocation, c.keyName, redactSecret(c.key))
+}
+
+// redact replaces every occurrence of the credential secrets in 's'.
+func (c AuthCredentials) redact(s string) string {
+	for _, secret := range []string{c.password, c.token, c.key} {
+		if secret == "" {
+			continue
+		}
+		s = strings.ReplaceAll(s, secret, redacted)
+		s = strings.ReplaceAll(s, url.QueryEscape(secret), redacted)
+	}
+	return s
+}
+
+// redactSecret hides a non-empty secret.
+func redactSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return redacted
 }
 
 // Headers represents HTTP headers as a map.
 type Headers map[string]string
 
+// String returns a description of the request suitable for logs and debug dumps.
+// Credentials in the URL, headers and auth configuration are redacted.
+func (c RequestInfo[Response]) String() string {
+	headers := make(Headers, len(c.headers))
+	for k, v := range c.headers {
+		if strings.EqualFold(k, HeaderAuthorization) || strings.EqualFold(k, c.authCredentials.keyName) {
+			v = redacted
+		}
+		headers[k] = c.authCredentials.redact(v)
+	}
+	return fmt.Sprintf("{method:%s url:%s authType:%s authCredentials:%s headers:%v}",
+		c.method, c.authCredentials.redact(c.url), c.authType, c.authCredentials, headers)
+}
+
 // RequestInfoOption modifies a RequestInfo instance.
 type RequestInfoOption[Response any] func(c *RequestInfo[Response])
 
@@ -44,6 +116,8 @@ func NewRequestInfo[Response any](method string, url string, body any, bodySeria
 		bodySerializer: bodySerializer,
 		responseParser: responseParser,
 		headers:        make(Headers),
+		maxResponse:    maxResponseBytes.Load(),
+		hooks:          defaultHooks(),
 	}
 
 	for _, option := range options {
@@ -68,6 +142,54 @@ func WithAuthBearer[Response any](token string) RequestInfoOption[Response] {
 	}
 }
 
+// WithAuthAPIKey sets API key authentication for the request.
+// 'location' selects a header or query parameter, 'name' is the header or parameter name.
+func WithAuthAPIKey[Response any](location APIKeyLocation, name, value string) RequestInfoOption[Response] {
+	return func(c *RequestInfo[Response]) {
+		c.authType = AuthTypeAPIKey
+		c.authCredentials = AuthCredentials{keyLocation: location, keyName: name, key: value}
+	}
+}
+
+// WithContext sets the context that controls cancellation and deadline of the request.
+func WithContext[Response any](ctx context.Context) RequestInfoOption[Response] {
+	return func(c *RequestInfo[Response]) {
+		c.ctx = ctx
+	}
+}
+
+// requestContext returns the request context, context.Background if none was set.
+func (c *RequestInfo[Response]) requestContext() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// WithRequestCompression compresses request bodies of at least 'minSize' bytes with the content
+// encoding 'encoding', e.g. "gzip" or "zstd", and sets the Content-Encoding header accordingly.
+// Zero or less compresses every body.
+func WithRequestCompression[Response any](encoding string, minSize int) RequestInfoOption[Response] {
+	return func(c *RequestInfo[Response]) {
+		c.compression = encoding
+		c.compressionMin = minSize
+	}
+}
+
+// WithBodySerializer replaces the serializer of the request body.
+func WithBodySerializer[Response any](serializer RequestBodySerializer) RequestInfoOption[Response] {
+	return func(c *RequestInfo[Response]) {
+		c.bodySerializer = serializer
+	}
+}
+
+// WithResponseParser replaces the parser of the response body.
+func WithResponseParser[Response any](parser ResponseBodyParser[Response]) RequestInfoOption[Response] {
+	return func(c *RequestInfo[Response]) {
+		c.responseParser = parser
+	}
+}
+
 // WithHeader adds a header to the request.
 func WithHeader[Response any](key, value string) RequestInfoOption[Response] {
 	return func(c *RequestInfo[Response]) {
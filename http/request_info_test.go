@@ -1,7 +1,11 @@
 package http
 
 import (
+	"context"
+	"fmt"
 	"github.com/guhungry/gohungry/http/httptest"
+	"io"
+	"strings"
 	"testing"
 )
 
@@ -72,6 +76,29 @@ func TestNewRequestInfo(t *testing.T) {
 				headers: make(Headers),
 			},
 		},
+		{
+			name:   "With API Key",
+			method: MethodGet,
+			url:    "http://example.com",
+			body:   nil,
+			options: []RequestInfoOption[any]{
+				WithAuthAPIKey[any](APIKeyInQuery, "api_key", "some-key"),
+			},
+			expected: RequestInfo[any]{
+				method:         "GET",
+				url:            "http://example.com",
+				body:           nil,
+				bodySerializer: httptest.DummyRequestBodySerializer,
+				responseParser: httptest.DummyResponseBodyParser[any],
+				authType:       AuthTypeAPIKey,
+				authCredentials: AuthCredentials{
+					keyLocation: APIKeyInQuery,
+					keyName:     "api_key",
+					key:         "some-key",
+				},
+				headers: make(Headers),
+			},
+		},
 		{
 			name:   "With Headers",
 			method: "DELETE",
@@ -116,6 +143,9 @@ func equalRequestInfo(a, b *RequestInfo[any]) bool {
 		a.authCredentials.username == b.authCredentials.username &&
 		a.authCredentials.password == b.authCredentials.password &&
 		a.authCredentials.token == b.authCredentials.token &&
+		a.authCredentials.keyLocation == b.authCredentials.keyLocation &&
+		a.authCredentials.keyName == b.authCredentials.keyName &&
+		a.authCredentials.key == b.authCredentials.key &&
 		equalHeaders(a.headers, b.headers)
 }
 
@@ -149,6 +179,88 @@ func TestWithAuthBearer(t *testing.T) {
 	}
 }
 
+func TestWithAuthAPIKey(t *testing.T) {
+	reqInfo := &RequestInfo[any]{}
+	WithAuthAPIKey[any](APIKeyInHeader, "X-API-Key", "some-key")(reqInfo)
+	if reqInfo.authType != AuthTypeAPIKey || reqInfo.authCredentials.keyLocation != APIKeyInHeader ||
+		reqInfo.authCredentials.keyName != "X-API-Key" || reqInfo.authCredentials.key != "some-key" {
+		t.Errorf("WithAuthAPIKey() failed: %+v", reqInfo)
+	}
+}
+
+func TestRequestInfoStringRedactsSecrets(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		options []RequestInfoOption[any]
+		secrets []string
+	}{
+		{
+			name:    "Basic Auth",
+			url:     "http://example.com",
+			options: []RequestInfoOption[any]{WithAuthBasic[any]("user", "p4ssw0rd")},
+			secrets: []string{"p4ssw0rd"},
+		},
+		{
+			name:    "Bearer Token In Header",
+			url:     "http://example.com",
+			options: []RequestInfoOption[any]{WithHeader[any](HeaderAuthorization, "Bearer some-token")},
+			secrets: []string{"some-token"},
+		},
+		{
+			name:    "API Key In URL",
+			url:     "http://example.com?api_key=some-key",
+			options: []RequestInfoOption[any]{WithAuthAPIKey[any](APIKeyInQuery, "api_key", "some-key")},
+			secrets: []string{"some-key"},
+		},
+		{
+			name:    "API Key In Header",
+			url:     "http://example.com",
+			options: []RequestInfoOption[any]{WithAuthAPIKey[any](APIKeyInHeader, "X-API-Key", "some-key"), WithHeader[any]("X-API-Key", "other-key")},
+			secrets: []string{"some-key", "other-key"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reqInfo := NewRequestInfo(MethodGet, tt.url, nil, httptest.DummyRequestBodySerializer, httptest.DummyResponseBodyParser[any], tt.options...)
+			for _, dump := range []string{reqInfo.String(), fmt.Sprintf("%+v", reqInfo), fmt.Sprintf("%v", *reqInfo)} {
+				for _, secret := range tt.secrets {
+					if strings.Contains(dump, secret) {
+						t.Errorf("Expected %s to be redacted, got %s", secret, dump)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "value")
+	reqInfo := &RequestInfo[any]{}
+	if reqInfo.requestContext() != context.Background() {
+		t.Errorf("Expected background context by default")
+	}
+	WithContext[any](ctx)(reqInfo)
+	if reqInfo.requestContext() != ctx {
+		t.Errorf("WithContext() failed: %+v", reqInfo.ctx)
+	}
+}
+
+func TestWithBodySerializerAndResponseParser(t *testing.T) {
+	reqInfo := NewRequestInfo[any](MethodPost, "http://example.com", "body", httptest.DummyRequestBodySerializer, httptest.DummyResponseBodyParser[any],
+		WithBodySerializer[any](func(body any) ([]byte, error) { return []byte("custom"), nil }),
+		WithResponseParser[any](func(reader io.ReadCloser) (*any, error) { return nil, io.ErrUnexpectedEOF }),
+	)
+	if body, _ := reqInfo.bodySerializer(nil); string(body) != "custom" {
+		t.Errorf("WithBodySerializer() failed: %s", body)
+	}
+	if _, err := reqInfo.responseParser(nil); err != io.ErrUnexpectedEOF {
+		t.Errorf("WithResponseParser() failed: %v", err)
+	}
+}
+
 func TestWithHeader(t *testing.T) {
 	reqInfo := &RequestInfo[any]{headers: make(Headers)}
 	WithHeader[any]("Custom-Header", "value")(reqInfo)
@@ -2,10 +2,13 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"github.com/guhungry/gohungry/http/httptest"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"testing"
 )
 
@@ -122,6 +125,100 @@ func TestSetAuth(t *testing.T) {
 	}
 }
 
+// TestSetAuthAPIKey tests the setAuth function for API key authentication.
+func TestSetAuthAPIKey(t *testing.T) {
+	tests := []struct {
+		name          string
+		url           string
+		location      APIKeyLocation
+		expectedURL   string
+		expectedValue string
+	}{
+		{
+			name:          "Header",
+			url:           "http://example.com/path?page=2",
+			location:      APIKeyInHeader,
+			expectedURL:   "http://example.com/path?page=2",
+			expectedValue: "secret",
+		},
+		{
+			name:          "Query",
+			url:           "http://example.com/path",
+			location:      APIKeyInQuery,
+			expectedURL:   "http://example.com/path?api_key=secret",
+			expectedValue: "",
+		},
+		{
+			name:          "Query Merged With Existing Query",
+			url:           "http://example.com/path?page=2&api_key=old",
+			location:      APIKeyInQuery,
+			expectedURL:   "http://example.com/path?api_key=secret&page=2",
+			expectedValue: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := "X-API-Key"
+			if tt.location == APIKeyInQuery {
+				name = "api_key"
+			}
+			req, _ := http.NewRequest("GET", tt.url, nil)
+			requestInfo := &RequestInfo[any]{}
+			WithAuthAPIKey[any](tt.location, name, "secret")(requestInfo)
+			setAuth(req, requestInfo)
+
+			if req.URL.String() != tt.expectedURL {
+				t.Errorf("Expected URL '%s', got '%s'", tt.expectedURL, req.URL.String())
+			}
+			if req.Header.Get("X-API-Key") != tt.expectedValue {
+				t.Errorf("Expected X-API-Key header '%s', got '%s'", tt.expectedValue, req.Header.Get("X-API-Key"))
+			}
+		})
+	}
+}
+
+// TestDoRequestRedactsAPIKey tests that API keys do not leak through returned errors.
+func TestDoRequestRedactsAPIKey(t *testing.T) {
+	SetHTTPClient(&httptest.MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			return nil, &url.Error{Op: "Get", URL: req.URL.String(), Err: errors.New("network error")}
+		},
+	})
+	defer ResetHTTPClient()
+
+	requestInfo := NewRequestInfo(MethodGet, "https://example.com", nil, httptest.DummyRequestBodySerializer, httptest.DummyResponseBodyParser[any],
+		WithAuthAPIKey[any](APIKeyInQuery, "api_key", "s3cr3t/key"))
+	_, err := DoRequest(requestInfo)
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	if strings.Contains(err.Error(), "s3cr3t") {
+		t.Errorf("Expected API key to be redacted, got %s", err)
+	}
+	if !strings.Contains(err.Error(), "api_key="+redacted) {
+		t.Errorf("Expected redacted api_key parameter, got %s", err)
+	}
+}
+
+// TestDoRequestWithContext tests that the request carries the context from WithContext.
+func TestDoRequestWithContext(t *testing.T) {
+	SetHTTPClient(&httptest.MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			return nil, req.Context().Err()
+		},
+	})
+	defer ResetHTTPClient()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	requestInfo := NewRequestInfo(MethodGet, "https://example.com", nil, httptest.DummyRequestBodySerializer, httptest.DummyResponseBodyParser[any],
+		WithContext[any](ctx))
+	if _, err := DoRequest(requestInfo); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context canceled, got %v", err)
+	}
+}
+
 // TestToBodyReader tests the toBodyReader function.
 func TestToBodyReader(t *testing.T) {
 	tests := []struct {
@@ -145,6 +242,20 @@ func TestToBodyReader(t *testing.T) {
 			expectedBody:  "dummy body",
 			expectedError: nil,
 		},
+		{
+			name:          "Streaming Body",
+			body:          StreamBody{Reader: strings.NewReader("streamed body")},
+			serializer:    httptest.DummyRequestBodySerializer,
+			expectedBody:  "streamed body",
+			expectedError: nil,
+		},
+		{
+			name:          "Reader Body Is Serialized",
+			body:          strings.NewReader("reader body"),
+			serializer:    httptest.DummyRequestBodySerializer,
+			expectedBody:  "dummy body",
+			expectedError: nil,
+		},
 		{
 			name:          "Serialization Error",
 			body:          map[string]string{"key": "value"},
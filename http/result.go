@@ -0,0 +1,40 @@
+package http
+
+import "net/http"
+
+// CacheStatus reports how a response cache answered a request.
+type CacheStatus string
+
+// Cache status constants, empty when the request did not go through a cache
+const (
+	CacheMiss        CacheStatus = "MISS"        // Response fetched from the upstream
+	CacheHit         CacheStatus = "HIT"         // Fresh response served from the cache
+	CacheRevalidated CacheStatus = "REVALIDATED" // Stored response confirmed by the upstream with 304 Not Modified
+)
+
+// Result describes the HTTP response behind a decoded response object.
+type Result struct {
+	StatusCode  int         // HTTP status code
+	Header      http.Header // Response headers
+	CacheStatus CacheStatus // Cache status reported by a cache middleware
+	Timings     Timings     // Timing breakdown, set when the request uses WithTimings
+}
+
+// WithResult fills 'result' with details of the HTTP response once the request completes.
+// It can be given several times, e.g. by a caller and by a helper wrapping the request, to fill each result.
+func WithResult[Response any](result *Result) RequestInfoOption[Response] {
+	return func(c *RequestInfo[Response]) {
+		if result != nil {
+			c.results = append(c.results, result)
+		}
+	}
+}
+
+// setResults copies the details of 'res' into every one of 'results'.
+func setResults(results []*Result, res *http.Response) {
+	for _, result := range results {
+		result.StatusCode = res.StatusCode
+		result.Header = res.Header
+		result.CacheStatus = CacheStatus(res.Header.Get(HeaderCacheStatus))
+	}
+}
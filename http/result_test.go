@@ -0,0 +1,34 @@
+package http
+
+import (
+	"bytes"
+	"github.com/guhungry/gohungry/http/httptest"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestWithResult(t *testing.T) {
+	SetHTTPClient(&httptest.MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			header := http.Header{}
+			header.Set("X-Request-Id", "42")
+			header.Set(HeaderCacheStatus, string(CacheHit))
+			return &http.Response{StatusCode: http.StatusAccepted, Header: header, Body: io.NopCloser(bytes.NewBufferString(`{}`))}, nil
+		},
+	})
+	defer ResetHTTPClient()
+
+	var result, other Result
+	requestInfo := NewRequestInfo(MethodGet, "https://example.com", nil, httptest.DummyRequestBodySerializer, httptest.DummyResponseBodyParser[any],
+		WithResult[any](&result), WithResult[any](&other))
+	if _, err := DoRequest(requestInfo); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for _, result := range []Result{result, other} {
+		if result.StatusCode != http.StatusAccepted || result.Header.Get("X-Request-Id") != "42" || result.CacheStatus != CacheHit {
+			t.Errorf("WithResult() failed: %+v", result)
+		}
+	}
+}
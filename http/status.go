@@ -0,0 +1,35 @@
+package http
+
+import (
+	"bytes"
+	"io"
+)
+
+// ResponseErrorParser converts the body of a response with a status code outside 2xx into an error.
+// Returning nil parses the response as usual.
+type ResponseErrorParser func(statusCode int, body io.Reader) error
+
+// WithErrorParser parses responses with a status code outside 2xx with 'parser' instead of the response parser.
+func WithErrorParser[Response any](parser ResponseErrorParser) RequestInfoOption[Response] {
+	return func(c *RequestInfo[Response]) {
+		c.errorParser = parser
+	}
+}
+
+// isSuccess reports whether 'statusCode' is 2xx.
+func isSuccess(statusCode int) bool {
+	return statusCode >= 200 && statusCode < 300
+}
+
+// parseErrorResponse reads 'body' and converts it with 'parser'. When 'parser' returns nil,
+// it returns a reader over the same bytes for the response parser.
+func parseErrorResponse(statusCode int, body io.Reader, parser ResponseErrorParser) (io.ReadCloser, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	if err := parser(statusCode, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
@@ -0,0 +1,51 @@
+package http
+
+import (
+	"errors"
+	"github.com/guhungry/gohungry/http/httptest"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDoRequestWithErrorParser(t *testing.T) {
+	errNotFound := errors.New("not found")
+	parser := func(statusCode int, body io.Reader) error {
+		data, _ := io.ReadAll(body)
+		if statusCode == http.StatusNotFound {
+			return errors.Join(errNotFound, errors.New(string(data)))
+		}
+		return nil
+	}
+	tests := []struct {
+		name          string
+		statusCode    int
+		expectedError error
+	}{
+		{name: "Success", statusCode: http.StatusOK},
+		{name: "Error Parsed", statusCode: http.StatusNotFound, expectedError: errNotFound},
+		{name: "Error Ignored By Parser", statusCode: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetHTTPClient(&httptest.MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					return &http.Response{StatusCode: tt.statusCode, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(`{"message":"body"}`))}, nil
+				},
+			})
+			defer ResetHTTPClient()
+
+			requestInfo := NewRequestInfo(MethodGet, "https://example.com", nil, httptest.DummyRequestBodySerializer, httptest.DummyResponseBodyParser[map[string]string],
+				WithErrorParser[map[string]string](parser))
+			response, err := DoRequest(requestInfo)
+			if !errors.Is(err, tt.expectedError) {
+				t.Fatalf("Expected %v, got %v", tt.expectedError, err)
+			}
+			if tt.expectedError == nil && (*response)["message"] != "body" {
+				t.Errorf("Expected the body to be parsed, got %v", response)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timings breaks down the time spent on a request. Network phases that did not happen,
+// e.g. DNS and connect on a reused connection, are zero.
+type Timings struct {
+	Serialize        time.Duration // Serializing the request body
+	DNS              time.Duration // Resolving the host name
+	Connect          time.Duration // Establishing the TCP connection
+	TLSHandshake     time.Duration // Performing the TLS handshake
+	ConnReused       bool          // Whether an idle keep-alive connection was reused
+	ServerProcessing time.Duration // From the request being written to the first response byte
+	ContentTransfer  time.Duration // From the first response byte until the response body was decoded
+	Parse            time.Duration // Parsing the response body, which overlaps ContentTransfer as bodies are streamed
+	Total            time.Duration // Time DoRequest took
+}
+
+// String returns the timings in a compact form suitable for logs.
+func (t Timings) String() string {
+	return fmt.Sprintf("{serialize:%v dns:%v connect:%v tls:%v reused:%t server:%v transfer:%v parse:%v total:%v}",
+		t.Serialize, t.DNS, t.Connect, t.TLSHandshake, t.ConnReused, t.ServerProcessing, t.ContentTransfer, t.Parse, t.Total)
+}
+
+// WithTimings traces the request with net/http/httptrace. The Timings are logged when the request
+// completes and set on the Result from WithResult.
+func WithTimings[Response any]() RequestInfoOption[Response] {
+	return func(c *RequestInfo[Response]) {
+		c.timings = true
+	}
+}
+
+// timingHook collects the Timings of one request.
+type timingHook struct {
+	results []*Result
+
+	mu           sync.Mutex
+	timings      Timings
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+	decodeEnd    time.Time
+}
+
+// newTimingHook creates a hook setting the Timings on every one of 'results'.
+func newTimingHook(results []*Result) *timingHook {
+	return &timingHook{results: results}
+}
+
+// RequestStart attaches the client trace to the request context.
+func (h *timingHook) RequestStart(ctx context.Context, _ *RequestEvent) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { h.start(&h.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { h.done(&h.dnsStart, &h.timings.DNS) },
+		ConnectStart: func(string, string) {
+			// Dual stack dialing may start several connections, the first start counts
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			if h.connectStart.IsZero() {
+				h.connectStart = time.Now()
+			}
+		},
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				h.done(&h.connectStart, &h.timings.Connect)
+			}
+		},
+		TLSHandshakeStart: func() { h.start(&h.tlsStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { h.done(&h.tlsStart, &h.timings.TLSHandshake) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			h.timings.ConnReused = info.Reused
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { h.start(&h.wroteRequest) },
+		GotFirstResponseByte: func() { h.start(&h.firstByte) },
+	})
+}
+
+// RequestSend does nothing, the client trace is carried by the request context.
+func (h *timingHook) RequestSend(context.Context, *http.Request) {}
+
+// StageEnd records the serialize and parse durations.
+func (h *timingHook) StageEnd(_ context.Context, stage Stage, start time.Time, _ error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	switch stage {
+	case StageSerialize:
+		h.timings.Serialize = time.Since(start)
+	case StageDecode:
+		h.decodeEnd = time.Now()
+		h.timings.Parse = h.decodeEnd.Sub(start)
+	}
+}
+
+// RequestEnd completes the Timings, sets them on the result and logs them.
+func (h *timingHook) RequestEnd(_ context.Context, event *RequestEvent) {
+	h.mu.Lock()
+	timings := h.timings
+	timings.Total = event.Duration
+	if !h.wroteRequest.IsZero() && !h.firstByte.IsZero() {
+		timings.ServerProcessing = h.firstByte.Sub(h.wroteRequest)
+	}
+	if !h.firstByte.IsZero() {
+		end := h.decodeEnd
+		if end.IsZero() {
+			end = event.Start.Add(event.Duration)
+		}
+		timings.ContentTransfer = end.Sub(h.firstByte)
+	}
+	h.mu.Unlock()
+
+	for _, result := range h.results {
+		result.Timings = timings
+	}
+	log.Println("request timings:", event.Method, event.URL, event.StatusCode, timings)
+}
+
+// start records the current time in 'at'.
+func (h *timingHook) start(at *time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	*at = time.Now()
+}
+
+// done sets 'duration' to the time since 'start'.
+func (h *timingHook) done(start *time.Time, duration *time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !start.IsZero() {
+		*duration = time.Since(*start)
+	}
+}
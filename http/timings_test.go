@@ -0,0 +1,79 @@
+package http
+
+import (
+	"net/http"
+	nethttptest "net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guhungry/gohungry/http/httptest"
+)
+
+func TestDoRequestTimings(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		_, _ = w.Write([]byte(`{"message":"success"}`))
+	})
+	tlsServer := nethttptest.NewTLSServer(handler)
+	defer tlsServer.Close()
+	server := nethttptest.NewServer(handler)
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		client    HTTPClient
+		url       string
+		expectDNS bool
+		expectTLS bool
+	}{
+		{name: "TLS", client: tlsServer.Client(), url: tlsServer.URL, expectDNS: false, expectTLS: true},
+		{name: "Host Name", client: &http.Client{Transport: &http.Transport{}}, url: strings.Replace(server.URL, "127.0.0.1", "localhost", 1), expectDNS: true, expectTLS: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetHTTPClient(tt.client)
+			defer ResetHTTPClient()
+
+			for _, reused := range []bool{false, true} {
+				var result Result
+				requestInfo := NewRequestInfo(MethodPost, tt.url, map[string]string{}, httptest.DummyRequestBodySerializer, httptest.DummyResponseBodyParser[map[string]string],
+					WithTimings[map[string]string](), WithResult[map[string]string](&result))
+				if _, err := DoRequest(requestInfo); err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+
+				timings := result.Timings
+				if timings.ConnReused != reused {
+					t.Errorf("Expected reused %v, got %+v", reused, timings)
+				}
+				newConnection := !reused
+				if (timings.Connect > 0) != newConnection || (timings.DNS > 0) != (newConnection && tt.expectDNS) || (timings.TLSHandshake > 0) != (newConnection && tt.expectTLS) {
+					t.Errorf("Expected DNS %v, connect and TLS %v on a new connection, got %+v", tt.expectDNS, tt.expectTLS, timings)
+				}
+				if timings.ServerProcessing < 10*time.Millisecond || timings.ContentTransfer <= 0 || timings.Serialize <= 0 || timings.Parse <= 0 {
+					t.Errorf("Expected server processing, transfer, serialize and parse durations, got %+v", timings)
+				}
+				if timings.Total < timings.ServerProcessing+timings.Connect {
+					t.Errorf("Expected total to cover the phases, got %+v", timings)
+				}
+			}
+		})
+	}
+}
+
+func TestDoRequestWithoutTimings(t *testing.T) {
+	SetHTTPClient(httptest.MockHTTPClientSuccess(http.StatusOK, `{"message":"success"}`))
+	defer ResetHTTPClient()
+
+	var result Result
+	requestInfo := NewRequestInfo(MethodGet, "https://example.com", nil, httptest.DummyRequestBodySerializer, httptest.DummyResponseBodyParser[map[string]string],
+		WithResult[map[string]string](&result))
+	if _, err := DoRequest(requestInfo); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Timings != (Timings{}) {
+		t.Errorf("Expected no timings, got %+v", result.Timings)
+	}
+}
@@ -0,0 +1,146 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrInvalidCACertificate is returned when a root CA contains no PEM encoded certificate.
+var ErrInvalidCACertificate = errors.New("gohungry: no valid CA certificate found")
+
+// newTLSConfig builds the TLS configuration described by 'config'.
+func newTLSConfig(config *clientConfig) (*tls.Config, error) {
+	result := &tls.Config{MinVersion: config.minTLSVersion}
+
+	rootCAs, err := loadRootCAs(config)
+	if err != nil {
+		return nil, err
+	}
+	result.RootCAs = rootCAs
+
+	switch {
+	case config.certFile != "" && config.reloadInterval > 0:
+		reloader, err := newCertificateReloader(config.certFile, config.keyFile, config.reloadInterval)
+		if err != nil {
+			return nil, err
+		}
+		result.GetClientCertificate = reloader.GetClientCertificate
+	case config.certFile != "":
+		cert, err := tls.LoadX509KeyPair(config.certFile, config.keyFile)
+		if err != nil {
+			return nil, err
+		}
+		result.Certificates = []tls.Certificate{cert}
+	case config.certPEM != nil:
+		cert, err := tls.X509KeyPair(config.certPEM, config.keyPEM)
+		if err != nil {
+			return nil, err
+		}
+		result.Certificates = []tls.Certificate{cert}
+	}
+	return result, nil
+}
+
+// loadRootCAs returns the system roots extended with the configured CAs.
+// It returns nil, meaning the system roots, when no CA is configured.
+func loadRootCAs(config *clientConfig) (*x509.CertPool, error) {
+	if len(config.rootCAFiles) == 0 && len(config.rootCAs) == 0 {
+		return nil, nil
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	certPEMs := config.rootCAs
+	for _, file := range config.rootCAFiles {
+		certPEM, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		certPEMs = append(certPEMs, certPEM)
+	}
+	for _, certPEM := range certPEMs {
+		if !pool.AppendCertsFromPEM(certPEM) {
+			return nil, ErrInvalidCACertificate
+		}
+	}
+	return pool, nil
+}
+
+// certificateReloader serves a client certificate and reloads it when its files change on disk.
+type certificateReloader struct {
+	certFile  string
+	keyFile   string
+	interval  time.Duration
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+// newCertificateReloader loads the certificate from 'certFile' and 'keyFile'.
+func newCertificateReloader(certFile, keyFile string, interval time.Duration) (*certificateReloader, error) {
+	result := &certificateReloader{certFile: certFile, keyFile: keyFile, interval: interval}
+	if err := result.reload(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetClientCertificate returns the current certificate, reloading it first when the files changed.
+// It matches tls.Config.GetClientCertificate.
+func (r *certificateReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.checkedAt) >= r.interval {
+		if err := r.reload(); err != nil {
+			// Keep serving the previous certificate until the files are valid again
+			log.Println("reload certificate error:", err)
+		}
+	}
+	return r.cert, nil
+}
+
+// reload loads the certificate when the files are newer than the current one.
+func (r *certificateReloader) reload() error {
+	r.checkedAt = time.Now()
+
+	modTime, err := latestModTime(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	if r.cert != nil && !modTime.After(r.modTime) {
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.cert = &cert
+	r.modTime = modTime
+	return nil
+}
+
+// latestModTime returns the most recent modification time of 'files'.
+func latestModTime(files ...string) (time.Time, error) {
+	var result time.Time
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(result) {
+			result = info.ModTime()
+		}
+	}
+	return result, nil
+}
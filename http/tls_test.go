@@ -0,0 +1,232 @@
+package http
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	nethttptest "net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCertificate holds a PEM encoded certificate and key created for tests.
+type testCertificate struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// newTestCertificate creates a certificate signed by 'parent', or self-signed when 'parent' is nil.
+func newTestCertificate(t *testing.T, commonName string, parent *testCertificate) *testCertificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  parent == nil,
+	}
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	return &testCertificate{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+// writeTestCertificate writes 'cert' to PEM files in 'dir' and returns their paths.
+func writeTestCertificate(t *testing.T, dir string, cert *testCertificate) (string, string) {
+	t.Helper()
+	certFile, keyFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	if err := os.WriteFile(certFile, cert.certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, cert.keyPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+// newMutualTLSServer starts a server that only accepts clients signed by 'clientCA'.
+func newMutualTLSServer(t *testing.T, clientCA *testCertificate) (*nethttptest.Server, []byte) {
+	t.Helper()
+	server := nethttptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCA.cert)
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	serverCAPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	return server, serverCAPEM
+}
+
+func TestNewClientMutualTLS(t *testing.T) {
+	ca := newTestCertificate(t, "test ca", nil)
+	client := newTestCertificate(t, "test client", ca)
+	server, serverCAPEM := newMutualTLSServer(t, ca)
+
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCertificate(t, dir, client)
+	caFile := filepath.Join(dir, "ca.crt")
+	if err := os.WriteFile(caFile, serverCAPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		options []ClientOption
+	}{
+		{
+			name:    "PEM Bytes",
+			options: []ClientOption{WithClientCertificate(client.certPEM, client.keyPEM), WithRootCAs(serverCAPEM)},
+		},
+		{
+			name:    "PEM Files",
+			options: []ClientOption{WithClientCertificateFiles(certFile, keyFile), WithRootCAFiles(caFile)},
+		},
+		{
+			name:    "PEM Files With Reload",
+			options: []ClientOption{WithClientCertificateFiles(certFile, keyFile), WithRootCAFiles(caFile), WithCertificateReload(time.Minute)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpClient, err := NewClient(tt.options...)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			res, err := httpClient.Get(server.URL)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			defer res.Body.Close()
+			if res.StatusCode != http.StatusOK {
+				t.Errorf("Expected status 200, got %d", res.StatusCode)
+			}
+		})
+	}
+}
+
+func TestNewClientWithoutClientCertificate(t *testing.T) {
+	ca := newTestCertificate(t, "test ca", nil)
+	server, serverCAPEM := newMutualTLSServer(t, ca)
+
+	httpClient, err := NewClient(WithRootCAs(serverCAPEM))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := httpClient.Get(server.URL); err == nil {
+		t.Error("Expected handshake error without client certificate, got nil")
+	}
+}
+
+func TestNewClientErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		options       []ClientOption
+		expectedError error
+	}{
+		{
+			name:          "Invalid Root CA",
+			options:       []ClientOption{WithRootCAs([]byte("not a certificate"))},
+			expectedError: ErrInvalidCACertificate,
+		},
+		{
+			name:          "Missing Root CA File",
+			options:       []ClientOption{WithRootCAFiles("missing.crt")},
+			expectedError: os.ErrNotExist,
+		},
+		{
+			name:          "Missing Certificate File",
+			options:       []ClientOption{WithClientCertificateFiles("missing.crt", "missing.key")},
+			expectedError: os.ErrNotExist,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewClient(tt.options...)
+			if !errors.Is(err, tt.expectedError) {
+				t.Errorf("NewClient() error = %v, expectedError %v", err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestNewClientMinTLSVersion(t *testing.T) {
+	httpClient, err := NewClient(WithMinTLSVersion(tls.VersionTLS13))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	transport := httpClient.Transport.(*http.Transport)
+	if transport.TLSClientConfig.MinVersion != tls.VersionTLS13 {
+		t.Errorf("Expected TLS 1.3, got %x", transport.TLSClientConfig.MinVersion)
+	}
+}
+
+func TestCertificateReloader(t *testing.T) {
+	ca := newTestCertificate(t, "test ca", nil)
+	first := newTestCertificate(t, "first", ca)
+	second := newTestCertificate(t, "second", ca)
+
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCertificate(t, dir, first)
+	reloader, err := newCertificateReloader(certFile, keyFile, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	assertCommonName := func(expected string) {
+		t.Helper()
+		cert, _ := reloader.GetClientCertificate(nil)
+		leaf, _ := x509.ParseCertificate(cert.Certificate[0])
+		if leaf.Subject.CommonName != expected {
+			t.Errorf("Expected certificate %s, got %s", expected, leaf.Subject.CommonName)
+		}
+	}
+	assertCommonName("first")
+
+	// Rotate the certificate on disk
+	writeTestCertificate(t, dir, second)
+	later := time.Now().Add(time.Minute)
+	_ = os.Chtimes(certFile, later, later)
+	assertCommonName("second")
+
+	// Broken files keep the previous certificate
+	_ = os.WriteFile(certFile, []byte("broken"), 0o600)
+	evenLater := later.Add(time.Minute)
+	_ = os.Chtimes(certFile, evenLater, evenLater)
+	assertCommonName("second")
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Errors wrapping the failures of Validator and WithResponseValidator
+var (
+	ErrInvalidRequest  = errors.New("gohungry: invalid request body")
+	ErrInvalidResponse = errors.New("gohungry: invalid response")
+)
+
+// Validator is implemented by request bodies and response types that can check themselves.
+// DoRequest validates request bodies before they are sent and responses after they are decoded.
+type Validator interface {
+	Validate() error
+}
+
+// BodyValidator checks a serialized request body or a raw response body before it is used.
+type BodyValidator func(body []byte) error
+
+// WithRequestBodyValidator checks the serialized request body with 'validator' before it is sent.
+// Streaming bodies are buffered to be validated.
+func WithRequestBodyValidator[Response any](validator BodyValidator) RequestInfoOption[Response] {
+	return func(c *RequestInfo[Response]) {
+		c.requestValidators = append(c.requestValidators, validator)
+	}
+}
+
+// WithResponseBodyValidator checks the decompressed response body with 'validator' before it is parsed.
+func WithResponseBodyValidator[Response any](validator BodyValidator) RequestInfoOption[Response] {
+	return func(c *RequestInfo[Response]) {
+		c.responseValidators = append(c.responseValidators, validator)
+	}
+}
+
+// WithResponseValidator checks the decoded response with 'validate', after its Validate method if it has one.
+func WithResponseValidator[Response any](validate func(response *Response) error) RequestInfoOption[Response] {
+	return func(c *RequestInfo[Response]) {
+		c.responseValidator = validate
+	}
+}
+
+// validateRequest runs the Validate method of 'body', if it has one.
+func validateRequest(body any) error {
+	if validator, ok := body.(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+		}
+	}
+	return nil
+}
+
+// validateResponse runs the Validate method of 'response', if it has one, then 'validate'.
+func validateResponse[Response any](response *Response, validate func(response *Response) error) error {
+	if response == nil {
+		return nil
+	}
+	if validator, ok := any(response).(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidResponse, err)
+		}
+	}
+	if validate != nil {
+		if err := validate(response); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidResponse, err)
+		}
+	}
+	return nil
+}
+
+// validateBody reads 'body' and checks it with 'validators'. It returns a reader over the same bytes.
+func validateBody(body io.Reader, validators []BodyValidator) (*bytes.Reader, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	for _, validator := range validators {
+		if err := validator(data); err != nil {
+			return nil, err
+		}
+	}
+	return bytes.NewReader(data), nil
+}
@@ -0,0 +1,113 @@
+package http
+
+import (
+	"errors"
+	"github.com/guhungry/gohungry/http/httptest"
+	"net/http"
+	"testing"
+)
+
+var errInvalidBody = errors.New("invalid body")
+
+func TestDoRequestBodyValidators(t *testing.T) {
+	rejectBody := func(expected string) BodyValidator {
+		return func(body []byte) error {
+			if string(body) == expected {
+				return errInvalidBody
+			}
+			return nil
+		}
+	}
+	tests := []struct {
+		name          string
+		body          any
+		options       []RequestInfoOption[map[string]string]
+		expectedError error
+		expectedSent  int
+	}{
+		{name: "Valid", body: "body", options: []RequestInfoOption[map[string]string]{WithRequestBodyValidator[map[string]string](rejectBody("")), WithResponseBodyValidator[map[string]string](rejectBody(""))}, expectedSent: 1},
+		{name: "Invalid Request", body: "body", options: []RequestInfoOption[map[string]string]{WithRequestBodyValidator[map[string]string](rejectBody("dummy body"))}, expectedError: errInvalidBody},
+		{name: "Invalid Response", body: "body", options: []RequestInfoOption[map[string]string]{WithResponseBodyValidator[map[string]string](rejectBody(`{"message":"success"}`))}, expectedError: errInvalidBody, expectedSent: 1},
+		{name: "No Request Body", body: nil, options: []RequestInfoOption[map[string]string]{WithRequestBodyValidator[map[string]string](rejectBody(""))}, expectedSent: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent int
+			SetHTTPClient(&httptest.MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					sent++
+					return httptest.MockHTTPClientSuccess(200, `{"message":"success"}`).Do(req)
+				},
+			})
+			defer ResetHTTPClient()
+
+			requestInfo := NewRequestInfo(MethodPost, "https://example.com", tt.body, httptest.DummyRequestBodySerializer, httptest.DummyResponseBodyParser[map[string]string], tt.options...)
+			response, err := DoRequest(requestInfo)
+			if !errors.Is(err, tt.expectedError) {
+				t.Fatalf("Expected %v, got %v", tt.expectedError, err)
+			}
+			if sent != tt.expectedSent {
+				t.Errorf("Expected %d requests sent, got %d", tt.expectedSent, sent)
+			}
+			if tt.expectedError == nil && (*response)["message"] != "success" {
+				t.Errorf("Expected parsed response after validation, got %v", response)
+			}
+		})
+	}
+}
+
+// validatedMessage rejects an empty message.
+type validatedMessage struct {
+	Message string `json:"message"`
+}
+
+func (m validatedMessage) Validate() error {
+	if m.Message == "" {
+		return errInvalidBody
+	}
+	return nil
+}
+
+func TestDoRequestValidator(t *testing.T) {
+	rejectSuccess := func(response *validatedMessage) error {
+		if response.Message == "success" {
+			return errInvalidBody
+		}
+		return nil
+	}
+	tests := []struct {
+		name          string
+		body          any
+		response      string
+		options       []RequestInfoOption[validatedMessage]
+		expectedError error
+	}{
+		{name: "Valid", body: validatedMessage{Message: "hi"}, response: `{"message":"success"}`},
+		{name: "Invalid Request", body: validatedMessage{}, response: `{"message":"success"}`, expectedError: ErrInvalidRequest},
+		{name: "Invalid Response", body: nil, response: `{}`, expectedError: ErrInvalidResponse},
+		{
+			name:          "Invalid Response From Function",
+			body:          nil,
+			response:      `{"message":"success"}`,
+			options:       []RequestInfoOption[validatedMessage]{WithResponseValidator(rejectSuccess)},
+			expectedError: ErrInvalidResponse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetHTTPClient(httptest.MockHTTPClientSuccess(200, tt.response))
+			defer ResetHTTPClient()
+
+			requestInfo := NewRequestInfo(MethodPost, "https://example.com", tt.body, httptest.DummyRequestBodySerializer, httptest.DummyResponseBodyParser[validatedMessage], tt.options...)
+			_, err := DoRequest(requestInfo)
+			if !errors.Is(err, tt.expectedError) {
+				t.Fatalf("Expected %v, got %v", tt.expectedError, err)
+			}
+			if tt.expectedError != nil && !errors.Is(err, errInvalidBody) {
+				t.Errorf("Expected the validation error to be wrapped, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package xml
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// ErrUnsupportedCharset is returned when a document declares a charset without a registered reader.
+var ErrUnsupportedCharset = errors.New("gohungry: unsupported XML charset")
+
+// CharsetReaderFunc converts 'input' from a charset to UTF-8.
+type CharsetReaderFunc func(input io.Reader) io.Reader
+
+// charsets holds the readers for the charsets of <?xml encoding="..."?> and Content-Type, keyed by lowercase name.
+var charsets = struct {
+	mu      sync.RWMutex
+	readers map[string]CharsetReaderFunc
+}{readers: make(map[string]CharsetReaderFunc)}
+
+// RegisterCharset makes 'reader' handle documents declaring any of 'names', case-insensitively.
+// It replaces a reader already registered for the same name.
+func RegisterCharset(reader CharsetReaderFunc, names ...string) {
+	charsets.mu.Lock()
+	defer charsets.mu.Unlock()
+	for _, name := range names {
+		charsets.readers[strings.ToLower(name)] = reader
+	}
+}
+
+func init() {
+	RegisterCharset(func(input io.Reader) io.Reader { return input }, "utf-8", "utf8", "us-ascii", "ascii")
+	RegisterCharset(fromEncoding(charmap.ISO8859_1), "iso-8859-1", "latin1", "l1")
+	RegisterCharset(fromEncoding(charmap.ISO8859_15), "iso-8859-15", "latin9")
+	RegisterCharset(fromEncoding(charmap.Windows1252), "windows-1252", "cp1252")
+	// Thai, TIS-620 and ISO-8859-11 are subsets of Windows-874
+	RegisterCharset(fromEncoding(charmap.Windows874), "windows-874", "cp874", "tis-620", "iso-8859-11")
+}
+
+// fromEncoding creates a CharsetReaderFunc decoding with 'e'.
+func fromEncoding(e encoding.Encoding) CharsetReaderFunc {
+	return func(input io.Reader) io.Reader {
+		return e.NewDecoder().Reader(input)
+	}
+}
+
+// CharsetReader converts 'input' declared as 'charset' to UTF-8 with the registered readers.
+// It matches the CharsetReader field of xml.Decoder.
+func CharsetReader(charset string, input io.Reader) (io.Reader, error) {
+	charsets.mu.RLock()
+	reader, ok := charsets.readers[strings.ToLower(charset)]
+	charsets.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCharset, charset)
+	}
+	return reader(input), nil
+}
+
+// NewDecoder creates an XML decoder reading from 'reader' that understands the registered charsets.
+func NewDecoder(reader io.Reader) *xml.Decoder {
+	decoder := xml.NewDecoder(reader)
+	decoder.CharsetReader = CharsetReader
+	return decoder
+}
+
+// NewResponseDecoder creates an XML decoder for a response body in the media type 'contentType'.
+// The charset parameter of 'contentType' takes precedence over the encoding of the XML declaration,
+// which is only used when the parameter is missing.
+func NewResponseDecoder(reader io.Reader, contentType string) (*xml.Decoder, error) {
+	_, params, _ := mime.ParseMediaType(contentType)
+	charset := params["charset"]
+	if charset == "" {
+		return NewDecoder(reader), nil
+	}
+
+	converted, err := CharsetReader(charset, reader)
+	if err != nil {
+		return nil, err
+	}
+	decoder := xml.NewDecoder(converted)
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		// Already converted to UTF-8 from the charset of the header
+		return input, nil
+	}
+	return decoder, nil
+}
@@ -0,0 +1,213 @@
+// Package soap calls SOAP 1.1 and 1.2 services. It wraps request bodies in an envelope with
+// optional header blocks, sets the SOAPAction, and decodes Fault elements into a *Fault error.
+package soap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/guhungry/gohungry/http"
+	"io"
+
+	gohungryxml "github.com/guhungry/gohungry/http/xml"
+)
+
+// Version selects the SOAP protocol version.
+type Version int
+
+// Supported SOAP versions
+const (
+	SOAP11 Version = iota // SOAP 1.1, the default
+	SOAP12                // SOAP 1.2
+)
+
+// Envelope namespaces of the SOAP versions
+const (
+	NamespaceSOAP11 = "http://schemas.xmlsoap.org/soap/envelope/"
+	NamespaceSOAP12 = "http://www.w3.org/2003/05/soap-envelope"
+)
+
+// HeaderSOAPAction is the HTTP header carrying the action of SOAP 1.1 requests.
+const HeaderSOAPAction = "SOAPAction"
+
+// ErrEmptyBody is returned when the response envelope has neither a body element nor a Fault.
+var ErrEmptyBody = errors.New("gohungry: empty SOAP body")
+
+// Request describes a SOAP call.
+type Request struct {
+	Version Version // Protocol version, defaults to SOAP11
+	Action  string  // SOAPAction of the operation, may be empty
+	Headers []any   // Header blocks, each marshaled into the envelope Header
+	Body    any     // Payload, marshaled into the envelope Body
+}
+
+// Call posts 'request' to 'url' in a SOAP envelope and decodes the body element of the response into 'Response'.
+// A Fault in the response is returned as a *Fault error. 'options' customize the request.
+func Call[Response any](url string, request Request, options ...http.RequestInfoOption[Response]) (*Response, error) {
+	contentType := "text/xml; charset=utf-8"
+	if request.Version == SOAP12 {
+		// SOAP 1.2 moves the action into the content type
+		contentType = "application/soap+xml; charset=utf-8"
+		if request.Action != "" {
+			contentType += fmt.Sprintf(`; action="%s"`, request.Action)
+		}
+	} else {
+		options = append(options, http.WithHeader[Response](HeaderSOAPAction, fmt.Sprintf(`"%s"`, request.Action)))
+	}
+	options = append(options,
+		http.WithAccept[Response](contentType),
+		http.WithContentType[Response](contentType),
+	)
+
+	var result http.Result
+	options = append(options, http.WithResult[Response](&result))
+	parser := func(reader io.ReadCloser) (*Response, error) {
+		return toResponseObject[Response](reader, result.Header.Get(http.HeaderContentType))
+	}
+	info := http.NewRequestInfo(http.MethodPost, url, request, marshalEnvelope, parser, options...)
+	return http.DoRequest(info)
+}
+
+// Fault is the error reported by a SOAP Fault element, normalized across SOAP versions.
+type Fault struct {
+	Code    string // faultcode in SOAP 1.1, Code/Value in SOAP 1.2
+	Subcode string // Code/Subcode/Value in SOAP 1.2
+	Reason  string // faultstring in SOAP 1.1, Reason/Text in SOAP 1.2
+	Actor   string // faultactor in SOAP 1.1, Role in SOAP 1.2
+	Detail  []byte // Raw XML content of the detail element
+}
+
+// Error formats the fault code and reason.
+func (f *Fault) Error() string {
+	code := f.Code
+	if f.Subcode != "" {
+		code += "/" + f.Subcode
+	}
+	return fmt.Sprintf("gohungry: SOAP fault %s: %s", code, f.Reason)
+}
+
+// DecodeDetail decodes the first element inside the detail element into 'v'.
+func (f *Fault) DecodeDetail(v any) error {
+	return gohungryxml.NewDecoder(bytes.NewReader(f.Detail)).Decode(v)
+}
+
+// faultXML matches the Fault elements of both SOAP versions.
+type faultXML struct {
+	Code struct {
+		Value   string `xml:"Value"`
+		Subcode struct {
+			Value string `xml:"Value"`
+		} `xml:"Subcode"`
+	} `xml:"Code"`
+	Reason struct {
+		Text string `xml:"Text"`
+	} `xml:"Reason"`
+	Role        string   `xml:"Role"`
+	Detail      innerXML `xml:"Detail"`
+	FaultCode   string   `xml:"faultcode"`
+	FaultString string   `xml:"faultstring"`
+	FaultActor  string   `xml:"faultactor"`
+	FaultDetail innerXML `xml:"detail"`
+}
+
+// innerXML captures the raw content of an element.
+type innerXML struct {
+	Content []byte `xml:",innerxml"`
+}
+
+// toFault normalizes a decoded Fault element.
+func (f *faultXML) toFault() *Fault {
+	if f.FaultCode != "" || f.FaultString != "" {
+		return &Fault{Code: f.FaultCode, Reason: f.FaultString, Actor: f.FaultActor, Detail: f.FaultDetail.Content}
+	}
+	return &Fault{Code: f.Code.Value, Subcode: f.Code.Subcode.Value, Reason: f.Reason.Text, Actor: f.Role, Detail: f.Detail.Content}
+}
+
+// envelope is the serialized form of a Request. The soap prefix keeps the namespace off the payload elements.
+type envelope struct {
+	XMLName   xml.Name  `xml:"soap:Envelope"`
+	Namespace string    `xml:"xmlns:soap,attr"`
+	Header    *innerXML `xml:"soap:Header"`
+	Body      innerXML  `xml:"soap:Body"`
+}
+
+// marshalEnvelope serializes a Request into a SOAP envelope preceded by the XML declaration.
+func marshalEnvelope(body any) ([]byte, error) {
+	request := body.(Request)
+	result := envelope{Namespace: NamespaceSOAP11}
+	if request.Version == SOAP12 {
+		result.Namespace = NamespaceSOAP12
+	}
+
+	if len(request.Headers) > 0 {
+		result.Header = &innerXML{}
+		for _, header := range request.Headers {
+			data, err := xml.Marshal(header)
+			if err != nil {
+				return nil, err
+			}
+			result.Header.Content = append(result.Header.Content, data...)
+		}
+	}
+	if request.Body != nil {
+		data, err := xml.Marshal(request.Body)
+		if err != nil {
+			return nil, err
+		}
+		result.Body.Content = data
+	}
+
+	data, err := xml.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// toResponseObject decodes the first element of the envelope Body in the media type 'contentType'
+// into 'Response', or returns its Fault.
+func toResponseObject[Response any](reader io.ReadCloser, contentType string) (*Response, error) {
+	decoder, err := gohungryxml.NewResponseDecoder(reader, contentType)
+	if err != nil {
+		return nil, err
+	}
+	inBody := false
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return nil, ErrEmptyBody
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch {
+		case !inBody:
+			// Skip the Envelope and Header, the payload starts after the Body element
+			if start.Name.Local == "Body" {
+				inBody = true
+			} else if start.Name.Local != "Envelope" {
+				if err := decoder.Skip(); err != nil {
+					return nil, err
+				}
+			}
+		case start.Name.Local == "Fault":
+			var fault faultXML
+			if err := decoder.DecodeElement(&fault, &start); err != nil {
+				return nil, err
+			}
+			return nil, fault.toFault()
+		default:
+			var result Response
+			if err := decoder.DecodeElement(&result, &start); err != nil {
+				return nil, err
+			}
+			return &result, nil
+		}
+	}
+}
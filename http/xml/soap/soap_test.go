@@ -0,0 +1,161 @@
+package soap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"github.com/guhungry/gohungry/http/httptest"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	gohungry "github.com/guhungry/gohungry/http"
+)
+
+type getUser struct {
+	XMLName xml.Name `xml:"urn:users GetUser"`
+	ID      int      `xml:"ID"`
+}
+
+type getUserResponse struct {
+	Name string `xml:"Name"`
+}
+
+type authHeader struct {
+	XMLName xml.Name `xml:"urn:auth Auth"`
+	Token   string   `xml:"Token"`
+}
+
+// mockSOAPClient records the request and replies with 'response'.
+func mockSOAPClient(request **http.Request, requestBody *string, statusCode int, response string) *httptest.MockHTTPClient {
+	return &httptest.MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			data, _ := io.ReadAll(req.Body)
+			*request, *requestBody = req, string(data)
+			return &http.Response{StatusCode: statusCode, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(response))}, nil
+		},
+	}
+}
+
+func TestCall(t *testing.T) {
+	const response = `<?xml version="1.0"?>
+<s:Envelope xmlns:s="%s"><s:Header><Ignored/></s:Header><s:Body><GetUserResponse xmlns="urn:users"><Name>Ann</Name></GetUserResponse></s:Body></s:Envelope>`
+	tests := []struct {
+		name                string
+		version             Version
+		namespace           string
+		expectedContentType string
+		expectedAction      string
+	}{
+		{name: "SOAP 1.1", version: SOAP11, namespace: NamespaceSOAP11, expectedContentType: "text/xml; charset=utf-8", expectedAction: `"urn:users#GetUser"`},
+		{name: "SOAP 1.2", version: SOAP12, namespace: NamespaceSOAP12, expectedContentType: `application/soap+xml; charset=utf-8; action="urn:users#GetUser"`, expectedAction: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req *http.Request
+			var body string
+			gohungry.SetHTTPClient(mockSOAPClient(&req, &body, 200, strings.Replace(response, "%s", tt.namespace, 1)))
+			defer gohungry.ResetHTTPClient()
+
+			result, err := Call[getUserResponse]("http://example.com", Request{
+				Version: tt.version,
+				Action:  "urn:users#GetUser",
+				Headers: []any{authHeader{Token: "t"}},
+				Body:    getUser{ID: 7},
+			})
+			if err != nil || result.Name != "Ann" {
+				t.Fatalf("Expected Ann, got %v, %v", result, err)
+			}
+
+			expectedBody := xml.Header + `<soap:Envelope xmlns:soap="` + tt.namespace + `">` +
+				`<soap:Header><Auth xmlns="urn:auth"><Token>t</Token></Auth></soap:Header>` +
+				`<soap:Body><GetUser xmlns="urn:users"><ID>7</ID></GetUser></soap:Body></soap:Envelope>`
+			if body != expectedBody {
+				t.Errorf("Expected body %s, got %s", expectedBody, body)
+			}
+			if contentType := req.Header.Get(gohungry.HeaderContentType); contentType != tt.expectedContentType {
+				t.Errorf("Expected Content-Type %s, got %s", tt.expectedContentType, contentType)
+			}
+			if action := req.Header.Get(HeaderSOAPAction); action != tt.expectedAction {
+				t.Errorf("Expected SOAPAction %s, got %s", tt.expectedAction, action)
+			}
+		})
+	}
+}
+
+func TestCallHeaderCharset(t *testing.T) {
+	const response = "<s:Envelope xmlns:s=\"" + NamespaceSOAP11 + "\"><s:Body><GetUserResponse xmlns=\"urn:users\"><Name>Ren\xe9</Name></GetUserResponse></s:Body></s:Envelope>"
+	gohungry.SetHTTPClient(&httptest.MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			header := http.Header{gohungry.HeaderContentType: {"text/xml; charset=ISO-8859-1"}}
+			return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(strings.NewReader(response))}, nil
+		},
+	})
+	defer gohungry.ResetHTTPClient()
+
+	result, err := Call[getUserResponse]("http://example.com", Request{Version: SOAP11, Body: getUser{ID: 7}})
+	if err != nil || result.Name != "René" {
+		t.Errorf("Expected René, got %v, %v", result, err)
+	}
+}
+
+func TestCallFault(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		expected Fault
+	}{
+		{
+			name: "SOAP 1.1",
+			response: `<soap:Envelope xmlns:soap="` + NamespaceSOAP11 + `"><soap:Body><soap:Fault>` +
+				`<faultcode>soap:Client</faultcode><faultstring>Unknown user</faultstring><faultactor>urn:users</faultactor>` +
+				`<detail><Code>404</Code></detail></soap:Fault></soap:Body></soap:Envelope>`,
+			expected: Fault{Code: "soap:Client", Reason: "Unknown user", Actor: "urn:users", Detail: []byte("<Code>404</Code>")},
+		},
+		{
+			name: "SOAP 1.2",
+			response: `<env:Envelope xmlns:env="` + NamespaceSOAP12 + `"><env:Body><env:Fault>` +
+				`<env:Code><env:Value>env:Sender</env:Value><env:Subcode><env:Value>m:NotFound</env:Value></env:Subcode></env:Code>` +
+				`<env:Reason><env:Text xml:lang="en">Unknown user</env:Text></env:Reason>` +
+				`<env:Detail><Code>404</Code></env:Detail></env:Fault></env:Body></env:Envelope>`,
+			expected: Fault{Code: "env:Sender", Subcode: "m:NotFound", Reason: "Unknown user", Detail: []byte("<Code>404</Code>")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req *http.Request
+			var body string
+			gohungry.SetHTTPClient(mockSOAPClient(&req, &body, 500, tt.response))
+			defer gohungry.ResetHTTPClient()
+
+			_, err := Call[getUserResponse]("http://example.com", Request{Body: getUser{ID: 7}})
+			var fault *Fault
+			if !errors.As(err, &fault) {
+				t.Fatalf("Expected *Fault, got %v", err)
+			}
+			if fault.Code != tt.expected.Code || fault.Subcode != tt.expected.Subcode || fault.Reason != tt.expected.Reason ||
+				fault.Actor != tt.expected.Actor || !bytes.Equal(fault.Detail, tt.expected.Detail) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, fault)
+			}
+
+			var code int
+			if err := fault.DecodeDetail(&code); err != nil || code != 404 {
+				t.Errorf("Expected detail code 404, got %d, %v", code, err)
+			}
+		})
+	}
+}
+
+func TestCallEmptyBody(t *testing.T) {
+	var req *http.Request
+	var body string
+	gohungry.SetHTTPClient(mockSOAPClient(&req, &body, 200, `<soap:Envelope xmlns:soap="`+NamespaceSOAP11+`"><soap:Body/></soap:Envelope>`))
+	defer gohungry.ResetHTTPClient()
+
+	if _, err := Call[getUserResponse]("http://example.com", Request{}); !errors.Is(err, ErrEmptyBody) {
+		t.Errorf("Expected ErrEmptyBody, got %v", err)
+	}
+}
@@ -19,7 +19,7 @@ func Get[Response any](url string, options ...http.RequestInfoOption[Response])
 }
 
 // Post performs an HTTP POST with XML body, decodes response into 'Response'.
-// 'url' is the request target, 'body' is the payload, 'options' customize the request.
+// 'url' is the request target, 'body' is the payload or an http.StreamBody, 'options' customize the request.
 func Post[Response any](url string, body any, options ...http.RequestInfoOption[Response]) (*Response, error) {
 	return requestXML[Response](http.MethodPost, url, body, options...)
 }
@@ -32,14 +32,37 @@ func requestXML[Response any](method string, url string, body any, options ...ht
 		http.WithAccept[Response](contentType),
 		http.WithContentType[Response](contentType),
 	)
-	request := http.NewRequestInfo(method, url, body, xml.Marshal, toResponseObject[Response], options...)
+	var result http.Result
+	options = append(options, http.WithResult[Response](&result))
+	parser := func(reader io.ReadCloser) (*Response, error) {
+		return toResponseObject[Response](reader, result.Header.Get(http.HeaderContentType))
+	}
+	request := http.NewRequestInfo(method, url, body, xml.Marshal, parser, options...)
 	return http.DoRequest(request)
 }
 
-// toResponseObject decodes XML into 'Response'.
-func toResponseObject[Response any](reader io.ReadCloser) (*Response, error) {
+// WithXMLDeclaration starts the serialized request body with the <?xml version="1.0" encoding="UTF-8"?> declaration.
+func WithXMLDeclaration[Response any]() http.RequestInfoOption[Response] {
+	return http.WithBodySerializer[Response](marshalWithDeclaration)
+}
+
+// marshalWithDeclaration serializes 'body' into XML preceded by the XML declaration.
+func marshalWithDeclaration(body any) ([]byte, error) {
+	data, err := xml.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// toResponseObject decodes XML in the media type 'contentType' into 'Response', converting the
+// registered charsets to UTF-8.
+func toResponseObject[Response any](reader io.ReadCloser, contentType string) (*Response, error) {
 	var result Response
-	decoder := xml.NewDecoder(reader)
+	decoder, err := NewResponseDecoder(reader, contentType)
+	if err != nil {
+		return nil, err
+	}
 	if err := decoder.Decode(&result); err != nil {
 		return nil, err
 	}
@@ -2,7 +2,11 @@ package xml
 
 import (
 	"encoding/xml"
+	"errors"
 	"github.com/guhungry/gohungry/http/httptest"
+	"io"
+	"net/http"
+	"strings"
 	"testing"
 
 	gohungry "github.com/guhungry/gohungry/http"
@@ -71,3 +75,77 @@ func TestPostWithInvalidXML(t *testing.T) {
 		t.Fatalf("Expected XML decoding error, got no error")
 	}
 }
+
+func TestGetWithCharset(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		expected    string
+	}{
+		{name: "Header Charset", contentType: "text/xml; charset=ISO-8859-1", body: "<MockResponse><message>caf\xe9</message></MockResponse>", expected: "café"},
+		{name: "Header Over Declaration", contentType: "text/xml; charset=windows-874", body: "<?xml version=\"1.0\" encoding=\"UTF-8\"?><MockResponse><message>\xa1</message></MockResponse>", expected: "ก"},
+		{name: "Header UTF-8 Over Declaration", contentType: "application/xml; charset=utf-8", body: "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?><MockResponse><message>café</message></MockResponse>", expected: "café"},
+		{name: "ISO-8859-1", body: "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?><MockResponse><message>caf\xe9</message></MockResponse>", expected: "café"},
+		{name: "Windows-874", body: "<?xml version=\"1.0\" encoding=\"windows-874\"?><MockResponse><message>\xca\xc7\xd1\xca\xb4\xd5</message></MockResponse>", expected: "สวัสดี"},
+		{name: "TIS-620", body: "<?xml version=\"1.0\" encoding=\"TIS-620\"?><MockResponse><message>\xa1</message></MockResponse>", expected: "ก"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gohungry.SetHTTPClient(&httptest.MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					header := http.Header{gohungry.HeaderContentType: {tt.contentType}}
+					return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(strings.NewReader(tt.body))}, nil
+				},
+			})
+			defer gohungry.ResetHTTPClient()
+
+			response, err := Get[MockResponse]("http://example.com")
+			if err != nil || response.Message != tt.expected {
+				t.Errorf("Expected %s, got %v, %v", tt.expected, response, err)
+			}
+		})
+	}
+}
+
+func TestGetWithUnsupportedCharset(t *testing.T) {
+	gohungry.SetHTTPClient(httptest.MockHTTPClientSuccess(200, `<?xml version="1.0" encoding="EBCDIC"?><MockResponse/>`))
+	defer gohungry.ResetHTTPClient()
+
+	_, err := Get[MockResponse]("http://example.com")
+	if !errors.Is(err, ErrUnsupportedCharset) {
+		t.Errorf("Expected ErrUnsupportedCharset, got %v", err)
+	}
+
+	gohungry.SetHTTPClient(&httptest.MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			header := http.Header{gohungry.HeaderContentType: {"text/xml; charset=EBCDIC"}}
+			return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(strings.NewReader(`<MockResponse/>`))}, nil
+		},
+	})
+	if _, err := Get[MockResponse]("http://example.com"); !errors.Is(err, ErrUnsupportedCharset) {
+		t.Errorf("Expected ErrUnsupportedCharset for the header charset, got %v", err)
+	}
+}
+
+func TestPostWithXMLDeclaration(t *testing.T) {
+	var received string
+	gohungry.SetHTTPClient(&httptest.MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			data, _ := io.ReadAll(req.Body)
+			received = string(data)
+			return httptest.MockHTTPClientSuccess(200, `<MockResponse/>`).Do(req)
+		},
+	})
+	defer gohungry.ResetHTTPClient()
+
+	requestBody := MockResponse{Message: "hi"}
+	if _, err := Post[MockResponse]("http://example.com", requestBody, WithXMLDeclaration[MockResponse]()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := xml.Header + `<MockResponse><message>hi</message></MockResponse>`
+	if received != expected {
+		t.Errorf("Expected body %s, got %s", expected, received)
+	}
+}
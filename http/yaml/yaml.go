@@ -0,0 +1,48 @@
+// Package yaml provides utilities for making HTTP requests with YAML payloads
+// and parsing YAML responses. It includes functions for performing GET and POST
+// requests with YAML content, along with customizable options for request configuration.
+package yaml
+
+import (
+	"github.com/guhungry/gohungry/http"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// contentType specifies the MIME type for YAML content.
+const contentType = "application/yaml"
+
+// Get performs an HTTP GET, decodes YAML response into 'Response'.
+// 'url' is the request target, 'options' customize the request.
+func Get[Response any](url string, options ...http.RequestInfoOption[Response]) (*Response, error) {
+	return requestYAML[Response](http.MethodGet, url, nil, options...)
+}
+
+// Post performs an HTTP POST with YAML body, decodes response into 'Response'.
+// 'url' is the request target, 'body' is the payload or an http.StreamBody, 'options' customize the request.
+func Post[Response any](url string, body any, options ...http.RequestInfoOption[Response]) (*Response, error) {
+	return requestYAML[Response](http.MethodPost, url, body, options...)
+}
+
+// requestYAML sends an HTTP request and decodes YAML response into 'Response'.
+// 'method' is the HTTP method, 'url' is the request target, 'body' is the payload for POST,
+// 'options' customize the request.
+func requestYAML[Response any](method string, url string, body any, options ...http.RequestInfoOption[Response]) (*Response, error) {
+	options = append(options,
+		http.WithAccept[Response](contentType),
+		http.WithContentType[Response](contentType),
+	)
+	request := http.NewRequestInfo(method, url, body, yaml.Marshal, toResponseObject[Response], options...)
+	return http.DoRequest(request)
+}
+
+// toResponseObject decodes YAML into 'Response'.
+func toResponseObject[Response any](reader io.ReadCloser) (*Response, error) {
+	var result Response
+	decoder := yaml.NewDecoder(reader)
+	if err := decoder.Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}